@@ -0,0 +1,38 @@
+resource "okta_auth_server" "default" {
+  name                      = "TerraformProviderTest"
+  audiences                 = ["api://terraform-provider-test"]
+  credentials_rotation_mode = "AUTO"
+}
+
+resource "okta_auth_server_scope" "read" {
+  auth_server_id = okta_auth_server.default.id
+  name           = "reports:read"
+}
+
+resource "okta_auth_server_claim" "groups" {
+  auth_server_id    = okta_auth_server.default.id
+  name              = "groups"
+  claim_type        = "RESOURCE"
+  value_type        = "GROUPS"
+  value             = "reports_"
+  group_filter_type = "STARTS_WITH"
+}
+
+resource "okta_auth_server_policy" "default" {
+  auth_server_id   = okta_auth_server.default.id
+  name             = "default"
+  description      = "Every client"
+  client_whitelist = ["ALL_CLIENTS"]
+}
+
+resource "okta_auth_server_policy_rule" "services" {
+  auth_server_id       = okta_auth_server.default.id
+  policy_id            = okta_auth_server_policy.default.id
+  name                 = "services"
+  grant_type_whitelist = ["client_credentials"]
+  scope_whitelist      = [okta_auth_server_scope.read.name]
+}
+
+output "issuer" {
+  value = okta_auth_server.default.issuer
+}
@@ -0,0 +1,14 @@
+resource "okta_app_aws_identity_center" "default" {
+  name                     = "TerraformProviderTest"
+  identity_center_metadata = file(var.metadata_file)
+  scim_endpoint_url        = var.scim_endpoint_url
+  scim_access_token        = var.scim_access_token
+}
+
+variable "metadata_file" { type = string }
+variable "scim_endpoint_url" { type = string }
+variable "scim_access_token" { type = string }
+
+output "saml_metadata_document" {
+  value = okta_app_aws_identity_center.default.saml_metadata_document
+}
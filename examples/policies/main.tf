@@ -0,0 +1,70 @@
+variable "staff_group_id" {}
+
+variable "office_zone_id" {}
+
+resource "okta_policy_signon" "staff" {
+  name            = "Staff"
+  groups_included = [var.staff_group_id]
+}
+
+resource "okta_policy_rule_signon" "office" {
+  policy_id          = okta_policy_signon.staff.id
+  name               = "Office"
+  network_connection = "ZONE"
+  network_includes   = [var.office_zone_id]
+  session_lifetime   = 480
+}
+
+resource "okta_policy_rule_signon" "offsite" {
+  policy_id        = okta_policy_signon.staff.id
+  name             = "Offsite"
+  priority         = 2
+  mfa_required     = true
+  mfa_prompt       = "SESSION"
+  mfa_lifetime     = 480
+  session_lifetime = 480
+}
+
+resource "okta_policy_password" "staff" {
+  name                          = "Staff"
+  groups_included               = [var.staff_group_id]
+  password_min_length           = 12
+  password_dictionary_lookup    = true
+  password_max_lockout_attempts = 5
+  password_auto_unlock_minutes  = 30
+}
+
+resource "okta_policy_rule_password" "self_service" {
+  policy_id       = okta_policy_password.staff.id
+  name            = "Self service"
+  password_unlock = "ALLOW"
+}
+
+resource "okta_policy_mfa_enroll" "staff" {
+  name            = "Staff"
+  groups_included = [var.staff_group_id]
+
+  factors = {
+    okta_otp   = "REQUIRED"
+    google_otp = "OPTIONAL"
+  }
+}
+
+resource "okta_policy_rule_mfa_enroll" "staff" {
+  policy_id = okta_policy_mfa_enroll.staff.id
+  name      = "Enroll at sign in"
+  enroll    = "LOGIN"
+}
+
+resource "okta_policy_app_signon" "payroll" {
+  name        = "Payroll"
+  description = "Payroll needs two factors every hour"
+}
+
+resource "okta_policy_rule_app_signon" "payroll" {
+  policy_id         = okta_policy_app_signon.payroll.id
+  name              = "Staff"
+  groups_included   = [var.staff_group_id]
+  factor_mode       = "2FA"
+  reauthenticate_in = "PT1H"
+}
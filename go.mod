@@ -3,9 +3,8 @@ module github.com/Brightspace/terraform-provider-okta
 go 1.12
 
 require (
-	github.com/cheekybits/is v0.0.0-20150225183255-68e9c0620927 // indirect
+	github.com/aws/aws-sdk-go v1.19.18
 	github.com/go-resty/resty/v2 v2.1.0
 	github.com/hashicorp/terraform v0.12.2
-	github.com/matryer/try v0.0.0-20161228173917-9ac251b645a2
 	golang.org/x/net v0.0.0-20190628185345-da137c7871d7
 )
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// OktaUserProfile is the profile of a user, or of a user in an app. App user
+// profiles are defined by the app's user schema, so the attributes without a
+// field are kept in Unknown.
+type OktaUserProfile struct {
+	Login       string   `json:"login,omitempty"`
+	FirstName   string   `json:"firstName,omitempty"`
+	LastName    string   `json:"lastName,omitempty"`
+	NickName    string   `json:"nickName,omitempty"`
+	DisplayName string   `json:"displayName,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	SecondEmail string   `json:"secondEmail,omitempty"`
+	Role        string   `json:"role,omitempty"`
+	SamlRoles   []string `json:"samlRoles,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+func (p OktaUserProfile) MarshalJSON() ([]byte, error) {
+	type plain OktaUserProfile
+	return marshalWithUnknown(plain(p), p.Unknown)
+}
+
+func (p *OktaUserProfile) UnmarshalJSON(data []byte) error {
+	type plain OktaUserProfile
+	unknown, err := unmarshalWithUnknown(data, (*plain)(p))
+	p.Unknown = unknown
+	return err
+}
+
+// Attributes returns every attribute of the profile, JSON encoded.
+func (p OktaUserProfile) Attributes() (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	return attributes, nil
+}
+
+// OktaAppUserAssignment is sent to assign a user to an app. Attributes left
+// out of the profile keep their current value, and null clears them.
+type OktaAppUserAssignment struct {
+	ID          string                     `json:"id"`
+	Scope       string                     `json:"scope"`
+	Credentials *OktaAppUserCredentials    `json:"credentials,omitempty"`
+	Profile     map[string]json.RawMessage `json:"profile"`
+}
+
+type OktaAppUserCredentials struct {
+	UserName string `json:"userName,omitempty"`
+}
+
+// NewAppUserAssignment returns the assignment of the user to an app, with
+// the profile attributes JSON encoded.
+func NewAppUserAssignment(userId string, profile map[string]interface{}) (OktaAppUserAssignment, error) {
+	assignment := OktaAppUserAssignment{
+		ID:      userId,
+		Scope:   "USER",
+		Profile: map[string]json.RawMessage{},
+	}
+
+	for name, value := range profile {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return assignment, fmt.Errorf("Could not encode profile attribute %q: %s", name, err)
+		}
+		assignment.Profile[name] = raw
+	}
+
+	return assignment, nil
+}
+
+// ValidateProfile checks each attribute is defined by the schema, with a
+// value of the type it declares and, when it lists them, one of the allowed
+// values. Null is always allowed, as it clears the attribute.
+func (s *OktaAppUserSchema) ValidateProfile(profile map[string]json.RawMessage) error {
+	names := make([]string, 0, len(profile))
+	for name := range profile {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Definitions.Custom.Properties[name]
+		if !ok {
+			property, ok = s.Definitions.Base.Properties[name]
+		}
+		if !ok {
+			return fmt.Errorf("Profile attribute %q is not in the app's user schema", name)
+		}
+
+		var value interface{}
+		if err := json.Unmarshal(profile[name], &value); err != nil {
+			return fmt.Errorf("Profile attribute %q is not valid JSON: %s", name, err)
+		}
+
+		if err := property.validate(name, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p OktaSchemaProperty) validate(name string, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch p.Type {
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("Profile attribute %q must be a string", name)
+		}
+
+		allowed := p.values()
+		if len(allowed) == 0 {
+			return nil
+		}
+		for _, candidate := range allowed {
+			if candidate == text {
+				return nil
+			}
+		}
+		return fmt.Errorf("Profile attribute %q can't be %q, expected one of %s", name, text, strings.Join(allowed, ", "))
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("Profile attribute %q must be a boolean", name)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("Profile attribute %q must be a number", name)
+		}
+	case "integer":
+		if number, ok := value.(float64); !ok || number != math.Trunc(number) {
+			return fmt.Errorf("Profile attribute %q must be an integer", name)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("Profile attribute %q must be an array", name)
+		}
+		if p.Items == nil {
+			return nil
+		}
+		for i, item := range items {
+			if err := p.Items.validate(fmt.Sprintf("%s[%d]", name, i), item); err != nil {
+				return err
+			}
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return fmt.Errorf("Profile attribute %q must be an object", name)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOktaUserProfile_keepsAppAttributes(t *testing.T) {
+	profile := OktaUserProfile{}
+	if err := json.Unmarshal([]byte(`{"role":"Developer","samlRoles":["Developer"],"sessionDuration":3600}`), &profile); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if profile.Role != "Developer" || string(profile.Unknown["sessionDuration"]) != "3600" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	attributes, err := profile.Attributes()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(attributes) != 3 || string(attributes["role"]) != `"Developer"` || string(attributes["sessionDuration"]) != "3600" {
+		t.Fatalf("unexpected attributes %s", attributes)
+	}
+}
+
+func TestOktaAppUserSchema_ValidateProfile(t *testing.T) {
+	appSchema := &OktaAppUserSchema{}
+	if err := json.Unmarshal([]byte(`{
+		"definitions": {
+			"base": {
+				"properties": {
+					"samlRoles": {"type": "array", "items": {"type": "string", "enum": ["ReadOnly", "Developer"]}},
+					"sessionDuration": {"type": "integer"}
+				}
+			},
+			"custom": {
+				"properties": {
+					"costCentre": {"type": "string"},
+					"contractor": {"type": "boolean"}
+				}
+			}
+		}
+	}`), appSchema); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	valid := map[string]json.RawMessage{
+		"samlRoles":       json.RawMessage(`["ReadOnly"]`),
+		"sessionDuration": json.RawMessage(`3600`),
+		"costCentre":      json.RawMessage(`"R&D"`),
+		"contractor":      json.RawMessage(`null`),
+	}
+	if err := appSchema.ValidateProfile(valid); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := map[string]map[string]json.RawMessage{
+		`"department" is not in the app's user schema`: {"department": json.RawMessage(`"IT"`)},
+		`"sessionDuration" must be an integer`:         {"sessionDuration": json.RawMessage(`1.5`)},
+		`"contractor" must be a boolean`:               {"contractor": json.RawMessage(`"yes"`)},
+		`"samlRoles[1]" can't be "Admin"`:              {"samlRoles": json.RawMessage(`["ReadOnly","Admin"]`)},
+	}
+
+	for expected, profile := range cases {
+		err := appSchema.ValidateProfile(profile)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error containing %s, got %v", expected, err)
+		}
+	}
+}
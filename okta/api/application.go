@@ -0,0 +1,414 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// The application model covers the parts of an Okta app the provider
+// manages. Anything else Okta returns is kept in Unknown at each level and
+// sent back as it was, so updating an app never drops settings the provider
+// doesn't know about.
+
+type OktaApplicationContents struct {
+	ID            string                        `json:"id,omitempty"`
+	Name          string                        `json:"name,omitempty"`
+	Label         string                        `json:"label,omitempty"`
+	Status        string                        `json:"status,omitempty"`
+	Features      []string                      `json:"features"`
+	SignOnMode    string                        `json:"signOnMode,omitempty"`
+	Accessibility *OktaApplicationAccessibility `json:"accessibility,omitempty"`
+	Visibility    *OktaApplicationVisibility    `json:"visibility,omitempty"`
+	Credentials   *OktaApplicationCredentials   `json:"credentials,omitempty"`
+	Settings      OktaApplicationSettings       `json:"settings,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplication struct {
+	OktaApplicationContents
+	Links OktaApplicationLinks `json:"_links,omitempty"`
+}
+
+type OktaApplicationAccessibility struct {
+	SelfService      bool    `json:"selfService"`
+	ErrorRedirectURL *string `json:"errorRedirectUrl,omitempty"`
+	LoginRedirectURL *string `json:"loginRedirectUrl,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationVisibility struct {
+	AutoSubmitToolbar bool                `json:"autoSubmitToolbar"`
+	Hide              OktaApplicationHide `json:"hide"`
+	AppLinks          map[string]bool     `json:"appLinks,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationHide struct {
+	IOS bool `json:"iOS"`
+	Web bool `json:"web"`
+}
+
+type OktaApplicationCredentials struct {
+	Signing          *OktaApplicationSigning          `json:"signing,omitempty"`
+	UserNameTemplate *OktaApplicationUserNameTemplate `json:"userNameTemplate,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationSigning struct {
+	KeyID string `json:"kid,omitempty"`
+}
+
+type OktaApplicationUserNameTemplate struct {
+	Template   string  `json:"template,omitempty"`
+	Type       string  `json:"type,omitempty"`
+	Suffix     *string `json:"suffix,omitempty"`
+	PushStatus string  `json:"pushStatus,omitempty"`
+}
+
+type OktaApplicationSettings struct {
+	App    OktaApplicationAppSettings     `json:"app,omitempty"`
+	SignOn *OktaApplicationSignOnSettings `json:"signOn,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationAppSettings struct {
+	AwsEnvironmentType  string `json:"awsEnvironmentType,omitempty"`
+	GroupFilter         string `json:"groupFilter,omitempty"`
+	LoginURL            string `json:"loginURL,omitempty"`
+	JoinAllRoles        *bool  `json:"joinAllRoles,omitempty"`
+	SessionDuration     int    `json:"sessionDuration,omitempty"`
+	RoleValuePattern    string `json:"roleValuePattern,omitempty"`
+	IdentityProviderArn string `json:"identityProviderArn,omitempty"`
+	AcsURL              string `json:"acsURL,omitempty"`
+	EntityID            string `json:"entityID,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationSignOnSettings struct {
+	DefaultRelayState   string                              `json:"defaultRelayState,omitempty"`
+	SsoAcsURLOverride   string                              `json:"ssoAcsUrlOverride,omitempty"`
+	AudienceOverride    string                              `json:"audienceOverride,omitempty"`
+	RecipientOverride   string                              `json:"recipientOverride,omitempty"`
+	DestinationOverride string                              `json:"destinationOverride,omitempty"`
+	AttributeStatements []OktaApplicationAttributeStatement `json:"attributeStatements,omitempty"`
+
+	Unknown map[string]json.RawMessage `json:"-"`
+}
+
+type OktaApplicationAttributeStatement struct {
+	Type      string   `json:"type"`
+	Name      string   `json:"name,omitempty"`
+	Namespace string   `json:"namespace,omitempty"`
+	Values    []string `json:"values,omitempty"`
+}
+
+type OktaApplicationLinks struct {
+	Metadata   *OktaLink  `json:"metadata,omitempty"`
+	AppLinks   []OktaLink `json:"appLinks,omitempty"`
+	Users      *OktaLink  `json:"users,omitempty"`
+	Groups     *OktaLink  `json:"groups,omitempty"`
+	Logo       []OktaLink `json:"logo,omitempty"`
+	Activate   *OktaLink  `json:"activate,omitempty"`
+	Deactivate *OktaLink  `json:"deactivate,omitempty"`
+}
+
+type OktaLink struct {
+	Name string `json:"name,omitempty"`
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+// DeprovisionsUsers reports whether Okta deactivates a user's account in
+// the app when they are unassigned from it.
+func (a *OktaApplication) DeprovisionsUsers() bool {
+	for _, feature := range a.Features {
+		if feature == "PUSH_USER_DEACTIVATION" {
+			return true
+		}
+	}
+	return false
+}
+
+// SigningKeyID returns the ID of the key the app signs SAML assertions
+// with, or an empty string when Okta didn't return one.
+func (a *OktaApplication) SigningKeyID() string {
+	if a.Credentials == nil || a.Credentials.Signing == nil {
+		return ""
+	}
+	return a.Credentials.Signing.KeyID
+}
+
+// Bool returns a pointer to the value, for the settings where false has to
+// be told apart from not set.
+func Bool(value bool) *bool {
+	return &value
+}
+
+// BoolValue returns the value pointed to, or false when there is none.
+func BoolValue(value *bool) bool {
+	return value != nil && *value
+}
+
+// String returns a pointer to the value, for the settings that are cleared
+// by sending an empty string, and left as they are when not sent at all.
+func String(value string) *string {
+	return &value
+}
+
+// StringValue returns the value pointed to, or an empty string when there is
+// none.
+func StringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// MergeApplication applies the update on top of the current app. Properties
+// the update leaves out, or sets to null, keep their current value, nested
+// objects are merged the same way and lists are replaced.
+func MergeApplication(current OktaApplicationContents, update OktaApplicationContents) (OktaApplicationContents, error) {
+	merged := OktaApplicationContents{}
+
+	currentJSON, err := json.Marshal(current)
+	if err != nil {
+		return merged, err
+	}
+
+	updateJSON, err := json.Marshal(update)
+	if err != nil {
+		return merged, err
+	}
+
+	var currentFields, updateFields map[string]interface{}
+	if err := json.Unmarshal(currentJSON, &currentFields); err != nil {
+		return merged, err
+	}
+	if err := json.Unmarshal(updateJSON, &updateFields); err != nil {
+		return merged, err
+	}
+
+	mergedJSON, err := json.Marshal(mergeJSONObjects(currentFields, updateFields))
+	if err != nil {
+		return merged, err
+	}
+
+	err = json.Unmarshal(mergedJSON, &merged)
+	return merged, err
+}
+
+func mergeJSONObjects(current map[string]interface{}, update map[string]interface{}) map[string]interface{} {
+	for key, value := range update {
+		if value == nil {
+			continue
+		}
+
+		currentObject, currentIsObject := current[key].(map[string]interface{})
+		updateObject, updateIsObject := value.(map[string]interface{})
+		if currentIsObject && updateIsObject {
+			current[key] = mergeJSONObjects(currentObject, updateObject)
+			continue
+		}
+
+		current[key] = value
+	}
+	return current
+}
+
+func (a OktaApplicationContents) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationContents
+	return marshalWithUnknown(plain(a), a.Unknown)
+}
+
+func (a *OktaApplicationContents) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationContents
+	unknown, err := unmarshalWithUnknown(data, (*plain)(a))
+
+	// Links and embedded resources are only ever returned by Okta, there's
+	// no point sending them back.
+	delete(unknown, "_links")
+	delete(unknown, "_embedded")
+
+	a.Unknown = unknown
+	return err
+}
+
+func (a OktaApplication) MarshalJSON() ([]byte, error) {
+	contents, err := json.Marshal(a.OktaApplicationContents)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := json.Marshal(a.Links)
+	if err != nil {
+		return nil, err
+	}
+
+	return marshalWithUnknown(json.RawMessage(contents), map[string]json.RawMessage{"_links": links})
+}
+
+func (a *OktaApplication) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.OktaApplicationContents); err != nil {
+		return err
+	}
+
+	links := struct {
+		Links OktaApplicationLinks `json:"_links"`
+	}{}
+	if err := json.Unmarshal(data, &links); err != nil {
+		return err
+	}
+
+	a.Links = links.Links
+	return nil
+}
+
+func (a OktaApplicationAccessibility) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationAccessibility
+	return marshalWithUnknown(plain(a), a.Unknown)
+}
+
+func (a *OktaApplicationAccessibility) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationAccessibility
+	unknown, err := unmarshalWithUnknown(data, (*plain)(a))
+	a.Unknown = unknown
+	return err
+}
+
+func (v OktaApplicationVisibility) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationVisibility
+	return marshalWithUnknown(plain(v), v.Unknown)
+}
+
+func (v *OktaApplicationVisibility) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationVisibility
+	unknown, err := unmarshalWithUnknown(data, (*plain)(v))
+	v.Unknown = unknown
+	return err
+}
+
+func (c OktaApplicationCredentials) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationCredentials
+	return marshalWithUnknown(plain(c), c.Unknown)
+}
+
+func (c *OktaApplicationCredentials) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationCredentials
+	unknown, err := unmarshalWithUnknown(data, (*plain)(c))
+	c.Unknown = unknown
+	return err
+}
+
+func (s OktaApplicationSettings) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationSettings
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+func (s *OktaApplicationSettings) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationSettings
+	unknown, err := unmarshalWithUnknown(data, (*plain)(s))
+	s.Unknown = unknown
+	return err
+}
+
+func (s OktaApplicationAppSettings) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationAppSettings
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+func (s *OktaApplicationAppSettings) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationAppSettings
+	unknown, err := unmarshalWithUnknown(data, (*plain)(s))
+	s.Unknown = unknown
+	return err
+}
+
+func (s OktaApplicationSignOnSettings) MarshalJSON() ([]byte, error) {
+	type plain OktaApplicationSignOnSettings
+	return marshalWithUnknown(plain(s), s.Unknown)
+}
+
+func (s *OktaApplicationSignOnSettings) UnmarshalJSON(data []byte) error {
+	type plain OktaApplicationSignOnSettings
+	unknown, err := unmarshalWithUnknown(data, (*plain)(s))
+	s.Unknown = unknown
+	return err
+}
+
+// marshalWithUnknown marshals the value, then adds the unknown properties
+// it doesn't already have.
+func marshalWithUnknown(value interface{}, unknown map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(value)
+	if err != nil || len(unknown) == 0 {
+		return data, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, raw := range unknown {
+		if _, ok := fields[key]; !ok {
+			fields[key] = raw
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// unmarshalWithUnknown unmarshals into the value, a pointer to a struct, and
+// returns the properties it has no field for.
+func unmarshalWithUnknown(data []byte, value interface{}) (map[string]json.RawMessage, error) {
+	if err := json.Unmarshal(data, value); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// Properties are matched to fields ignoring case, the same as
+	// encoding/json does.
+	for _, name := range jsonFieldNames(reflect.TypeOf(value).Elem()) {
+		for key := range fields {
+			if strings.EqualFold(key, name) {
+				delete(fields, key)
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
+}
+
+func jsonFieldNames(structType reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+
+		switch {
+		case name == "-":
+			continue
+		case field.Anonymous && field.Type.Kind() == reflect.Struct:
+			names = append(names, jsonFieldNames(field.Type)...)
+			continue
+		case name == "":
+			name = field.Name
+		}
+
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const applicationJSON = `{
+	"id": "0oa1b2c3d4e5f6g7h8i9",
+	"name": "amazon_aws",
+	"label": "example-aws",
+	"status": "ACTIVE",
+	"notes": {"admin": "Managed by Terraform"},
+	"features": ["PUSH_NEW_USERS"],
+	"signOnMode": "SAML_2_0",
+	"accessibility": {"selfService": false, "loginRedirectUrl": "https://example.com/login"},
+	"visibility": {"autoSubmitToolbar": false, "hide": {"iOS": true, "web": false}, "appLinks": {"login": true}},
+	"credentials": {"scheme": "EDIT_USERNAME_AND_PASSWORD", "signing": {"kid": "kid1"}, "userNameTemplate": {"template": "${source.login}", "type": "BUILT_IN"}},
+	"settings": {
+		"app": {"identityProviderArn": "arn:aws:iam::123456789012:saml-provider/OKTA", "loginURL": "https://console.aws.amazon.com/ec2/home", "useGroupMapping": true},
+		"notifications": {"vpn": {"network": {"connection": "DISABLED"}}},
+		"signOn": {"defaultRelayState": "state", "honorForceAuthn": true}
+	},
+	"_links": {"metadata": {"href": "https://example.okta.com/api/v1/apps/0oa1b2c3d4e5f6g7h8i9/sso/saml/metadata", "type": "application/xml"}}
+}`
+
+func TestOktaApplication_preservesUnknownSettings(t *testing.T) {
+	app := OktaApplication{}
+	if err := json.Unmarshal([]byte(applicationJSON), &app); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if app.SigningKeyID() != "kid1" || app.Credentials.UserNameTemplate.Template != "${source.login}" {
+		t.Fatalf("unexpected credentials %+v", app.Credentials)
+	}
+
+	if !app.Visibility.Hide.IOS || StringValue(app.Accessibility.LoginRedirectURL) != "https://example.com/login" {
+		t.Fatalf("unexpected visibility %+v and accessibility %+v", app.Visibility, app.Accessibility)
+	}
+
+	if app.Settings.SignOn.DefaultRelayState != "state" || app.Links.Metadata.Type != "application/xml" {
+		t.Fatalf("unexpected sign on settings %+v and links %+v", app.Settings.SignOn, app.Links)
+	}
+
+	body, err := json.Marshal(app.OktaApplicationContents)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	sent := map[string]interface{}{}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	settings := sent["settings"].(map[string]interface{})
+	for name, value := range map[string]interface{}{
+		"notes":                           sent["notes"],
+		"credentials.scheme":              sent["credentials"].(map[string]interface{})["scheme"],
+		"settings.notifications":          settings["notifications"],
+		"settings.app.useGroupMapping":    settings["app"].(map[string]interface{})["useGroupMapping"],
+		"settings.signOn.honorForceAuthn": settings["signOn"].(map[string]interface{})["honorForceAuthn"],
+	} {
+		if value == nil {
+			t.Fatalf("expected %s to be sent back, got %s", name, body)
+		}
+	}
+
+	if _, ok := settings["app"].(map[string]interface{})["loginUrl"]; ok {
+		t.Fatalf("expected loginURL to be sent once, got %s", body)
+	}
+
+	if _, ok := sent["_links"]; ok {
+		t.Fatalf("expected links not to be sent back, got %s", body)
+	}
+}
+
+func TestMergeApplication(t *testing.T) {
+	current := OktaApplication{}
+	if err := json.Unmarshal([]byte(applicationJSON), &current); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	update := OktaApplicationContents{
+		ID:    current.ID,
+		Label: "example-aws-renamed",
+		Settings: OktaApplicationSettings{
+			App: OktaApplicationAppSettings{
+				IdentityProviderArn: "arn:aws:iam::123456789012:saml-provider/OKTA-2",
+				JoinAllRoles:        Bool(false),
+			},
+		},
+	}
+
+	merged, err := MergeApplication(current.OktaApplicationContents, update)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if merged.Label != "example-aws-renamed" || merged.Name != "amazon_aws" || merged.SignOnMode != "SAML_2_0" {
+		t.Fatalf("unexpected app %+v", merged)
+	}
+
+	if merged.Settings.App.IdentityProviderArn != "arn:aws:iam::123456789012:saml-provider/OKTA-2" ||
+		merged.Settings.App.LoginURL != "https://console.aws.amazon.com/ec2/home" ||
+		merged.Settings.App.JoinAllRoles == nil || *merged.Settings.App.JoinAllRoles {
+		t.Fatalf("unexpected app settings %+v", merged.Settings.App)
+	}
+
+	if len(merged.Features) != 1 || merged.Visibility == nil || !merged.Visibility.Hide.IOS {
+		t.Fatalf("expected the features and visibility to be kept, got %+v", merged)
+	}
+
+	if merged.Unknown["notes"] == nil || merged.Settings.Unknown["notifications"] == nil {
+		t.Fatalf("expected the unknown settings to be kept, got %+v", merged)
+	}
+}
+
+func TestNewAwsApplicationUpdate_keepsSessionDuration(t *testing.T) {
+	current := NewAwsApplication("example-aws", "arn:aws:iam::123456789012:saml-provider/OKTA")
+	current.Settings.App.SessionDuration = 3600
+
+	merged, err := MergeApplication(current, NewAwsApplicationUpdate("0oa1", "example-aws-renamed", "arn:aws:iam::123456789012:saml-provider/OKTA-2"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if merged.Settings.App.SessionDuration != 3600 {
+		t.Fatalf("expected the session duration to be kept, got %d", merged.Settings.App.SessionDuration)
+	}
+}
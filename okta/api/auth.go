@@ -15,3 +15,15 @@ func NewHTTPSignature(key string) (map[string]string, error) {
 
 	return headers, nil
 }
+
+func NewBearerSignature(token string) (map[string]string, error) {
+	headers := make(map[string]string)
+
+	contentType := "application/json"
+
+	headers["Accept"] = contentType
+	headers["Content-Type"] = contentType
+	headers["Authorization"] = fmt.Sprintf("Bearer %s", token)
+
+	return headers, nil
+}
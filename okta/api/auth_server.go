@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// OktaAuthServer is a custom authorization server, which issues the access
+// tokens of APIs for the audiences it serves.
+type OktaAuthServer struct {
+	ID          string                     `json:"id,omitempty"`
+	Name        string                     `json:"name"`
+	Description string                     `json:"description,omitempty"`
+	Audiences   []string                   `json:"audiences"`
+	Issuer      string                     `json:"issuer,omitempty"`
+	IssuerMode  string                     `json:"issuerMode,omitempty"`
+	Status      string                     `json:"status,omitempty"`
+	Credentials *OktaAuthServerCredentials `json:"credentials,omitempty"`
+}
+
+type OktaAuthServerCredentials struct {
+	Signing OktaAuthServerSigning `json:"signing"`
+}
+
+// OktaAuthServerSigning is how the server's signing keys are rotated. Okta
+// rotates them itself in AUTO mode, in MANUAL mode they are only rotated on
+// request.
+type OktaAuthServerSigning struct {
+	RotationMode string     `json:"rotationMode,omitempty"`
+	KeyID        string     `json:"kid,omitempty"`
+	LastRotated  *time.Time `json:"lastRotated,omitempty"`
+	NextRotation *time.Time `json:"nextRotation,omitempty"`
+}
+
+type OktaAuthServerScope struct {
+	ID              string `json:"id,omitempty"`
+	Name            string `json:"name"`
+	DisplayName     string `json:"displayName,omitempty"`
+	Description     string `json:"description,omitempty"`
+	Consent         string `json:"consent,omitempty"`
+	MetadataPublish string `json:"metadataPublish,omitempty"`
+	Default         bool   `json:"default"`
+	System          bool   `json:"system,omitempty"`
+}
+
+// OktaAuthServerClaim adds a value to the access tokens (RESOURCE) or ID
+// tokens (IDENTITY) the server issues, from an expression or the groups the
+// user is in.
+type OktaAuthServerClaim struct {
+	ID                   string                         `json:"id,omitempty"`
+	Name                 string                         `json:"name"`
+	Status               string                         `json:"status,omitempty"`
+	ClaimType            string                         `json:"claimType"`
+	ValueType            string                         `json:"valueType"`
+	Value                string                         `json:"value"`
+	GroupFilterType      string                         `json:"group_filter_type,omitempty"`
+	AlwaysIncludeInToken bool                           `json:"alwaysIncludeInToken"`
+	Conditions           *OktaAuthServerClaimConditions `json:"conditions,omitempty"`
+	System               bool                           `json:"system,omitempty"`
+}
+
+type OktaAuthServerClaimConditions struct {
+	Scopes []string `json:"scopes"`
+}
+
+// OktaAuthServerPolicy decides which clients its rules apply to. Policies
+// are evaluated in order of priority, starting from 1.
+type OktaAuthServerPolicy struct {
+	ID          string                         `json:"id,omitempty"`
+	Type        string                         `json:"type"`
+	Name        string                         `json:"name"`
+	Description string                         `json:"description"`
+	Status      string                         `json:"status,omitempty"`
+	Priority    int                            `json:"priority,omitempty"`
+	Conditions  OktaAuthServerPolicyConditions `json:"conditions"`
+	System      bool                           `json:"system,omitempty"`
+}
+
+type OktaAuthServerPolicyConditions struct {
+	Clients OktaInclude `json:"clients"`
+}
+
+// OktaAuthServerPolicyRule grants tokens for the scopes to the people and
+// grant types it matches.
+type OktaAuthServerPolicyRule struct {
+	ID         string                             `json:"id,omitempty"`
+	Type       string                             `json:"type"`
+	Name       string                             `json:"name"`
+	Status     string                             `json:"status,omitempty"`
+	Priority   int                                `json:"priority,omitempty"`
+	Conditions OktaAuthServerPolicyRuleConditions `json:"conditions"`
+	Actions    OktaAuthServerPolicyRuleActions    `json:"actions"`
+	System     bool                               `json:"system,omitempty"`
+}
+
+type OktaAuthServerPolicyRuleConditions struct {
+	People     OktaPeopleCondition `json:"people"`
+	GrantTypes OktaInclude         `json:"grantTypes"`
+	Scopes     OktaInclude         `json:"scopes"`
+}
+
+type OktaPeopleCondition struct {
+	Users  OktaIncludeExclude `json:"users"`
+	Groups OktaIncludeExclude `json:"groups"`
+}
+
+type OktaInclude struct {
+	Include []string `json:"include"`
+}
+
+type OktaIncludeExclude struct {
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+}
+
+type OktaAuthServerPolicyRuleActions struct {
+	Token OktaAuthServerTokenActions `json:"token"`
+}
+
+// OktaAuthServerTokenActions are the lifetimes of the tokens a rule grants.
+// A refresh token lifetime of 0 never expires.
+type OktaAuthServerTokenActions struct {
+	AccessTokenLifetimeMinutes  int `json:"accessTokenLifetimeMinutes"`
+	RefreshTokenLifetimeMinutes int `json:"refreshTokenLifetimeMinutes"`
+	RefreshTokenWindowMinutes   int `json:"refreshTokenWindowMinutes"`
+}
+
+func authServerURL(serverID string, path ...interface{}) string {
+	url := fmt.Sprintf("/api/v1/authorizationServers/%s", serverID)
+	for _, part := range path {
+		url += fmt.Sprintf("/%s", part)
+	}
+	return url
+}
+
+func (o *Okta) CreateAuthServer(ctx context.Context, server OktaAuthServer) (*OktaAuthServer, error) {
+	result := &OktaAuthServer{}
+	if err := o.sendResource(ctx, resty.MethodPost, "/api/v1/authorizationServers", server, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetAuthServer returns the authorization server, or nil when it doesn't
+// exist.
+func (o *Okta) GetAuthServer(ctx context.Context, serverID string) (*OktaAuthServer, error) {
+	result := &OktaAuthServer{}
+	found, err := o.getResource(ctx, authServerURL(serverID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdateAuthServer(ctx context.Context, server OktaAuthServer) (*OktaAuthServer, error) {
+	result := &OktaAuthServer{}
+	if err := o.sendResource(ctx, resty.MethodPut, authServerURL(server.ID), server, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteAuthServer deletes the authorization server, which Okta only allows
+// once it is deactivated.
+func (o *Okta) DeleteAuthServer(ctx context.Context, serverID string) error {
+	return o.deleteResource(ctx, authServerURL(serverID))
+}
+
+// SetAuthServerActive activates or deactivates the authorization server.
+// Tokens aren't issued by an inactive server.
+func (o *Okta) SetAuthServerActive(ctx context.Context, serverID string, active bool) error {
+	return o.setLifecycle(ctx, authServerURL(serverID), active)
+}
+
+func (o *Okta) CreateAuthServerScope(ctx context.Context, serverID string, scope OktaAuthServerScope) (*OktaAuthServerScope, error) {
+	result := &OktaAuthServerScope{}
+	if err := o.sendResource(ctx, resty.MethodPost, authServerURL(serverID, "scopes"), scope, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) GetAuthServerScope(ctx context.Context, serverID string, scopeID string) (*OktaAuthServerScope, error) {
+	result := &OktaAuthServerScope{}
+	found, err := o.getResource(ctx, authServerURL(serverID, "scopes", scopeID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdateAuthServerScope(ctx context.Context, serverID string, scope OktaAuthServerScope) (*OktaAuthServerScope, error) {
+	result := &OktaAuthServerScope{}
+	if err := o.sendResource(ctx, resty.MethodPut, authServerURL(serverID, "scopes", scope.ID), scope, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) DeleteAuthServerScope(ctx context.Context, serverID string, scopeID string) error {
+	return o.deleteResource(ctx, authServerURL(serverID, "scopes", scopeID))
+}
+
+func (o *Okta) CreateAuthServerClaim(ctx context.Context, serverID string, claim OktaAuthServerClaim) (*OktaAuthServerClaim, error) {
+	result := &OktaAuthServerClaim{}
+	if err := o.sendResource(ctx, resty.MethodPost, authServerURL(serverID, "claims"), claim, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) GetAuthServerClaim(ctx context.Context, serverID string, claimID string) (*OktaAuthServerClaim, error) {
+	result := &OktaAuthServerClaim{}
+	found, err := o.getResource(ctx, authServerURL(serverID, "claims", claimID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdateAuthServerClaim(ctx context.Context, serverID string, claim OktaAuthServerClaim) (*OktaAuthServerClaim, error) {
+	result := &OktaAuthServerClaim{}
+	if err := o.sendResource(ctx, resty.MethodPut, authServerURL(serverID, "claims", claim.ID), claim, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) DeleteAuthServerClaim(ctx context.Context, serverID string, claimID string) error {
+	return o.deleteResource(ctx, authServerURL(serverID, "claims", claimID))
+}
+
+func (o *Okta) CreateAuthServerPolicy(ctx context.Context, serverID string, policy OktaAuthServerPolicy) (*OktaAuthServerPolicy, error) {
+	result := &OktaAuthServerPolicy{}
+	if err := o.sendResource(ctx, resty.MethodPost, authServerURL(serverID, "policies"), policy, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) GetAuthServerPolicy(ctx context.Context, serverID string, policyID string) (*OktaAuthServerPolicy, error) {
+	result := &OktaAuthServerPolicy{}
+	found, err := o.getResource(ctx, authServerURL(serverID, "policies", policyID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdateAuthServerPolicy(ctx context.Context, serverID string, policy OktaAuthServerPolicy) (*OktaAuthServerPolicy, error) {
+	result := &OktaAuthServerPolicy{}
+	if err := o.sendResource(ctx, resty.MethodPut, authServerURL(serverID, "policies", policy.ID), policy, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) DeleteAuthServerPolicy(ctx context.Context, serverID string, policyID string) error {
+	return o.deleteResource(ctx, authServerURL(serverID, "policies", policyID))
+}
+
+func (o *Okta) SetAuthServerPolicyActive(ctx context.Context, serverID string, policyID string, active bool) error {
+	return o.setLifecycle(ctx, authServerURL(serverID, "policies", policyID), active)
+}
+
+func (o *Okta) CreateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule OktaAuthServerPolicyRule) (*OktaAuthServerPolicyRule, error) {
+	result := &OktaAuthServerPolicyRule{}
+	if err := o.sendResource(ctx, resty.MethodPost, authServerURL(serverID, "policies", policyID, "rules"), rule, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) GetAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) (*OktaAuthServerPolicyRule, error) {
+	result := &OktaAuthServerPolicyRule{}
+	found, err := o.getResource(ctx, authServerURL(serverID, "policies", policyID, "rules", ruleID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule OktaAuthServerPolicyRule) (*OktaAuthServerPolicyRule, error) {
+	result := &OktaAuthServerPolicyRule{}
+	if err := o.sendResource(ctx, resty.MethodPut, authServerURL(serverID, "policies", policyID, "rules", rule.ID), rule, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) DeleteAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) error {
+	return o.deleteResource(ctx, authServerURL(serverID, "policies", policyID, "rules", ruleID))
+}
+
+func (o *Okta) SetAuthServerPolicyRuleActive(ctx context.Context, serverID string, policyID string, ruleID string, active bool) error {
+	return o.setLifecycle(ctx, authServerURL(serverID, "policies", policyID, "rules", ruleID), active)
+}
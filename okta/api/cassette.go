@@ -0,0 +1,282 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CassetteMode selects whether a Recorder captures live traffic or plays
+// back a cassette recorded earlier.
+type CassetteMode int
+
+const (
+	CassetteReplay CassetteMode = iota
+	CassetteRecord
+)
+
+// A Cassette is a sequence of requests made to Okta and the responses they
+// received, saved as JSON so tests can run against real response shapes
+// without network access. A synthetic cassette was written by hand rather
+// than recorded, recording it again replaces it with real traffic.
+type Cassette struct {
+	Synthetic    bool                  `json:"synthetic,omitempty"`
+	Interactions []CassetteInteraction `json:"interactions"`
+}
+
+type CassetteInteraction struct {
+	Request  CassetteRequest  `json:"request"`
+	Response CassetteResponse `json:"response"`
+}
+
+type CassetteRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   string `json:"body,omitempty"`
+}
+
+type CassetteResponse struct {
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// Response headers left out of cassettes, as they carry credentials or
+// vary between recordings without telling the client anything.
+var cassetteSkippedHeaders = map[string]bool{
+	"Content-Length":            true,
+	"Date":                      true,
+	"Set-Cookie":                true,
+	"Strict-Transport-Security": true,
+}
+
+var xsrfTokenPattern = regexp.MustCompile(`(<span[^>]*id="_xsrfToken"[^>]*>)[^<]*(</span>)`)
+
+// Recorder is an http.RoundTripper that records requests to a cassette, or
+// replays them from one. It can be given to the API client with
+// SetRestClient, and to the admin web client as its HTTPClient transport.
+//
+// Everything recorded is sanitised the same way as the debug logs: the host
+// is dropped, credentials in query strings, JSON and form bodies are
+// redacted, cookies are left out and the admin XSRF token is replaced. A
+// cassette is safe to commit once recorded.
+//
+// Replayed requests are matched in order by method, sanitised URL and
+// sanitised body, so a session token or XSRF token taken from a replayed
+// response matches the recording, and a change to what the client sends
+// fails the test rather than replaying a response to a different request.
+//
+// Replacements are applied to everything recorded, for instance to swap the
+// org's URL for https://example.okta.com.
+type Recorder struct {
+	Path         string
+	Mode         CassetteMode
+	Transport    http.RoundTripper
+	Replacements map[string]string
+
+	mu       sync.Mutex
+	cassette Cassette
+	next     int
+}
+
+// NewRecorder returns a recorder for the cassette at the given path. When
+// replaying, the cassette is loaded straight away.
+func NewRecorder(path string, mode CassetteMode) (*Recorder, error) {
+	recorder := &Recorder{
+		Path:      path,
+		Mode:      mode,
+		Transport: http.DefaultTransport,
+	}
+
+	if mode == CassetteRecord {
+		return recorder, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read cassette %s: %s", path, err)
+	}
+
+	if err := json.Unmarshal(contents, &recorder.cassette); err != nil {
+		return nil, fmt.Errorf("Could not parse cassette %s: %s", path, err)
+	}
+
+	return recorder, nil
+}
+
+func (r *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := CassetteRequest{
+		Method: request.Method,
+		URL:    redactURL(r.sanitise(request.URL.RequestURI())),
+		Body:   r.sanitise(sanitiseBody(request.Header.Get("Content-Type"), body)),
+	}
+
+	if r.Mode == CassetteRecord {
+		return r.record(request, recorded)
+	}
+
+	return r.replay(request, recorded)
+}
+
+func (r *Recorder) record(request *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	resp, err := r.Transport.RoundTrip(request)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	header := map[string]string{}
+	for name := range resp.Header {
+		if cassetteSkippedHeaders[name] {
+			continue
+		}
+
+		value := resp.Header.Get(name)
+		if name == "Location" {
+			value = redactURL(r.sanitise(value))
+		}
+		header[name] = r.sanitise(value)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Interactions = append(r.cassette.Interactions, CassetteInteraction{
+		Request: recorded,
+		Response: CassetteResponse{
+			Status: resp.StatusCode,
+			Header: header,
+			Body:   r.sanitise(sanitiseBody(resp.Header.Get("Content-Type"), body)),
+		},
+	})
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(request *http.Request, recorded CassetteRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.next >= len(r.cassette.Interactions) {
+		return nil, fmt.Errorf("Cassette %s has no interaction left for %s %s", r.Path, recorded.Method, recorded.URL)
+	}
+
+	interaction := r.cassette.Interactions[r.next]
+	if interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL {
+		return nil, fmt.Errorf("Cassette %s expected %s %s as interaction %d, got %s %s", r.Path, interaction.Request.Method, interaction.Request.URL, r.next+1, recorded.Method, recorded.URL)
+	}
+	if interaction.Request.Body != recorded.Body {
+		return nil, fmt.Errorf("Cassette %s expected %s %s as interaction %d to send %q, got %q", r.Path, recorded.Method, recorded.URL, r.next+1, interaction.Request.Body, recorded.Body)
+	}
+	r.next++
+
+	header := http.Header{}
+	for name, value := range interaction.Response.Header {
+		header.Set(name, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       request,
+	}, nil
+}
+
+// Remaining returns the number of interactions in the cassette that have
+// not been replayed, so tests can check every request expected was made.
+func (r *Recorder) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.cassette.Interactions) - r.next
+}
+
+// Save writes the recorded interactions to the cassette. It does nothing
+// when replaying.
+func (r *Recorder) Save() error {
+	if r.Mode != CassetteRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var contents bytes.Buffer
+	encoder := json.NewEncoder(&contents)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.cassette); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(r.Path, contents.Bytes(), 0644)
+}
+
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil {
+		return nil, nil
+	}
+
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func (r *Recorder) sanitise(value string) string {
+	for original, replacement := range r.Replacements {
+		value = strings.Replace(value, original, replacement, -1)
+	}
+	return value
+}
+
+func sanitiseBody(contentType string, body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+	switch {
+	case len(trimmed) == 0:
+		return ""
+	case bytes.HasPrefix(trimmed, []byte("{")), bytes.HasPrefix(trimmed, []byte("[")):
+		return redactJSON(body)
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return redactedValue
+		}
+		return redactForm(values)
+	}
+
+	return xsrfTokenPattern.ReplaceAllString(string(body), "${1}"+redactedValue+"${2}")
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const cassetteHostURL = "https://example.okta.com"
+const cassetteAdminURL = "https://example-admin.okta.com"
+
+// newCassette returns API and admin web clients that replay the named
+// cassette from testdata/cassettes. Run the tests with
+// OKTA_CASSETTE_MODE=record, and the provider's OKTA_* environment
+// variables set, to record it again against a live org.
+func newCassette(t *testing.T, name string) (*Recorder, *Okta, *OktaWebClient) {
+	path := filepath.Join("testdata", "cassettes", name+".json")
+
+	mode := CassetteReplay
+	if os.Getenv("OKTA_CASSETTE_MODE") == "record" {
+		mode = CassetteRecord
+	}
+
+	recorder, err := NewRecorder(path, mode)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	client := &Okta{HostURL: cassetteHostURL, APIKey: "api-key"}
+	web := &OktaWebClient{
+		HostURL:  cassetteHostURL,
+		AdminURL: cassetteAdminURL,
+		UserName: "admin@example.com",
+		Password: "password",
+		OrgID:    "00o1example",
+	}
+
+	if mode == CassetteRecord {
+		client.HostURL = os.Getenv("OKTA_URL")
+		client.APIKey = os.Getenv("OKTA_API_KEY")
+		web.HostURL = os.Getenv("OKTA_URL")
+		web.AdminURL = os.Getenv("OKTA_ADMIN_URL")
+		web.UserName = os.Getenv("OKTA_USERNAME")
+		web.Password = os.Getenv("OKTA_PASSWORD")
+		web.OrgID = os.Getenv("OKTA_ORG_ID")
+
+		recorder.Replacements = map[string]string{
+			web.AdminURL:   cassetteAdminURL,
+			client.HostURL: cassetteHostURL,
+		}
+	}
+
+	client.SetRestClient(resty.New().SetTransport(recorder))
+	web.HTTPClient = &http.Client{Transport: recorder}
+
+	return recorder, client, web
+}
+
+// finishCassette saves a cassette being recorded, or checks every request
+// in a replayed cassette was made.
+func finishCassette(t *testing.T, recorder *Recorder) {
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if recorder.Mode == CassetteReplay && recorder.Remaining() != 0 {
+		t.Fatalf("expected every interaction in %s to be replayed, %d left", recorder.Path, recorder.Remaining())
+	}
+}
+
+func hostOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	return parsed.Host
+}
+
+func TestRecorder_sanitisesRecording(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "session-cookie"})
+
+		switch r.URL.Path {
+		case "/api/v1/authn":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"status":"SUCCESS","sessionToken":"session-token"}`)
+		case "/admin/dashboard":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, `<html><span id="_xsrfToken">xsrf-token</span></html>`)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "provisioning.json")
+	recorder, err := NewRecorder(path, CassetteRecord)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	recorder.Replacements = map[string]string{server.URL: cassetteHostURL}
+
+	web := &OktaWebClient{
+		HostURL:    server.URL,
+		AdminURL:   server.URL,
+		UserName:   "admin@example.com",
+		Password:   "P@ssw0rd!",
+		HTTPClient: &http.Client{Transport: recorder},
+	}
+
+	if err := web.SetAWSProvisioning(context.Background(), "0oa1example", "AKIAEXAMPLE", "aws-secret"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, secret := range []string{"P@ssw0rd!", "session-token", "session-cookie", "xsrf-token", "AKIAEXAMPLE", "aws-secret", hostOf(server.URL)} {
+		if strings.Contains(string(contents), secret) {
+			t.Fatalf("expected %q to be left out of the cassette:\n%s", secret, contents)
+		}
+	}
+
+	// The sanitised cassette still replays, as the redacted tokens taken
+	// from the responses match the redacted requests.
+	replay, err := NewRecorder(path, CassetteReplay)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	web.HostURL = cassetteHostURL
+	web.AdminURL = cassetteHostURL
+	web.HTTPClient = &http.Client{Transport: replay}
+
+	if err := web.SetAWSProvisioning(context.Background(), "0oa1example", "AKIAOTHER", "other-secret"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if replay.Remaining() != 0 {
+		t.Fatalf("expected every interaction to be replayed, %d left", replay.Remaining())
+	}
+}
+
+func TestRecorder_replayRejectsUnexpectedRequest(t *testing.T) {
+	recorder, _, web := newCassette(t, "aws_provisioning")
+	if recorder.Mode == CassetteRecord {
+		t.Skip("nothing to check when recording")
+	}
+
+	web.HostURL = "https://example.okta.com/other"
+	err := web.RevokeAWSProvisioning(context.Background(), "0oa1example")
+	if err == nil || !strings.Contains(err.Error(), "expected POST /api/v1/authn") {
+		t.Fatalf("expected the request not to match the cassette, got %v", err)
+	}
+}
+
+func TestRecorder_replayRejectsChangedBody(t *testing.T) {
+	recorder, client, _ := newCassette(t, "recorder_changed_body")
+	if recorder.Mode == CassetteRecord {
+		t.Skip("nothing to check when recording")
+	}
+
+	_, err := client.CreateAwsApplication(context.Background(), "other-aws", "arn:aws:iam::123456789012:saml-provider/OKTA")
+	if err == nil || !strings.Contains(err.Error(), "expected POST /api/v1/apps as interaction 1 to send") {
+		t.Fatalf("expected the body not to match the cassette, got %v", err)
+	}
+}
@@ -1,88 +1,124 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
 )
 
-const MaximumRetryWaitTimeInSeconds = 15 * time.Minute
-const RetryWaitTimeInSeconds = 30 * time.Second
+type OktaUser struct {
+	ID              string          `json:"id"`
+	Status          string          `json:"status"`
+	Created         *time.Time      `json:"created,omitempty"`
+	Activated       *time.Time      `json:"activated,omitempty"`
+	StatusChanged   *time.Time      `json:"statusChanged,omitempty"`
+	LastLogin       *time.Time      `json:"lastLogin,omitempty"`
+	LastUpdated     *time.Time      `json:"lastUpdated,omitempty"`
+	PasswordChanged *time.Time      `json:"passwordChanged,omitempty"`
+	Profile         OktaUserProfile `json:"profile,omitempty"`
+	Credentials     struct {
+		UserName string `json:"userName,omitempty"`
+	} `json:"credentials,omitempty"`
 
-type OktaApplicationContents struct {
-	ID         string                  `json:"id"`
-	Name       string                  `json:"name"`
-	Label      string                  `json:"label"`
-	Features   []string                `json:"features"`
-	SignOnMode string                  `json:"signOnMode"`
-	Settings   OktaApplicationSettings `json:"settings,omitempty"`
+	// Set on users in an app. The scope is GROUP when the assignment comes
+	// from one of the user's groups, linked to as group.
+	Scope     string     `json:"scope,omitempty"`
+	SyncState string     `json:"syncState,omitempty"`
+	LastSync  *time.Time `json:"lastSync,omitempty"`
+	Links     struct {
+		Group *OktaLink `json:"group,omitempty"`
+	} `json:"_links,omitempty"`
 }
 
-type OktaApplication struct {
-	OktaApplicationContents
-	Credentials struct {
-		Signing struct {
-			KeyID string `json:"kid,omitempty"`
-		} `json:"signing,omitempty"`
-	} `json:"credentials,omitempty"`
+type OktaGroup struct {
+	ID      string `json:"id"`
+	Type    string `json:"type,omitempty"`
+	Profile struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+	} `json:"profile"`
 }
 
-type OktaApplicationSettings struct {
-	App OktaApplicationAppSettings `json:"app,omitempty"`
+// OktaAppUserSchema is the schema of the profile users have in an app. For
+// AWS apps it enumerates the roles discovered in the accounts.
+type OktaAppUserSchema struct {
+	Definitions struct {
+		Base   OktaSchemaDefinition `json:"base"`
+		Custom OktaSchemaDefinition `json:"custom"`
+	} `json:"definitions"`
 }
 
-type OktaApplicationAppSettings struct {
-	AwsEnvironmentType  string `json:"awsEnvironmentType,omitempty"`
-	GroupFilter         string `json:"groupFilter,omitempty"`
-	LoginURL            string `json:"loginUrl,omitempty"`
-	JoinAllRoles        bool   `json:"joinAllRoles,omitempty"`
-	SessionDuration     int    `json:"sessionDuration,omitempty"`
-	RoleValuePattern    string `json:"roleValuePattern,omitempty"`
-	IdentityProviderArn string `json:"identityProviderArn,omitempty"`
+type OktaSchemaDefinition struct {
+	Properties map[string]OktaSchemaProperty `json:"properties"`
 }
 
-type OktaUser struct {
-	ID              string     `json:"id"`
-	Status          string     `json:"status"`
-	Created         *time.Time `json:"created,omitempty"`
-	Activated       *time.Time `json:"activated,omitempty"`
-	StatusChanged   *time.Time `json:"statusChanged,omitempty"`
-	LastLogin       *time.Time `json:"lastLogin,omitempty"`
-	LastUpdated     *time.Time `json:"lastUpdated,omitempty"`
-	PasswordChanged *time.Time `json:"passwordChanged,omitempty"`
-	Profile         struct {
-		Login       string   `json:"login,omitempty"`
-		FirstName   string   `json:"firstName,omitempty"`
-		LastName    string   `json:"lastName,omitempty"`
-		NickName    string   `json:"nickName,omitempty"`
-		DisplayName string   `json:"displayName,omitempty"`
-		Email       string   `json:"email,omitempty"`
-		SecondEmail string   `json:"secondEmail,omitempty"`
-		Role        string   `json:"role,omitempty"`
-		SamlRoles   []string `json:"samlRoles,omitempty"`
-	} `json:"profile,omitempty"`
+type OktaSchemaProperty struct {
+	Title string              `json:"title,omitempty"`
+	Type  string              `json:"type,omitempty"`
+	Enum  []string            `json:"enum,omitempty"`
+	OneOf []OktaSchemaOption  `json:"oneOf,omitempty"`
+	Items *OktaSchemaProperty `json:"items,omitempty"`
+}
+
+type OktaSchemaOption struct {
+	Const string `json:"const"`
+	Title string `json:"title,omitempty"`
+}
+
+// values returns the values the property is limited to.
+func (p OktaSchemaProperty) values() []string {
+	values := []string{}
+	for _, option := range p.options() {
+		values = append(values, option.Const)
+	}
+	return values
+}
+
+// options returns the values the property is limited to, with their titles
+// when they have them.
+func (p OktaSchemaProperty) options() []OktaSchemaOption {
+	options := []OktaSchemaOption{}
+	for _, value := range p.Enum {
+		options = append(options, OktaSchemaOption{Const: value})
+	}
+	return append(options, p.OneOf...)
 }
 
 type Okta struct {
-	APIKey       string
-	HostURL      string
-	OrgID        string
-	RetryMaximum int
-	RestClient   *resty.Client
+	APIKey      string
+	HostURL     string
+	OrgID       string
+	OAuth       *OAuthCredentials
+	RetryPolicy RetryPolicy
+	RestClient  *resty.Client
+
+	// The number of requests allowed in flight at once, overall and to a
+	// single endpoint. Zero means no limit.
+	MaxConcurrentRequests          int
+	MaxConcurrentRequestsPerBucket int
+
+	restOnce   sync.Once
+	rateLimits *rateLimits
+	limiter    *requestLimiter
 }
 
-func (o *Okta) GetApplication(appID string) (*OktaApplication, error) {
+func (o *Okta) GetApplication(ctx context.Context, appID string) (*OktaApplication, error) {
 	restClient := o.GetRestClient()
 
 	url := fmt.Sprintf("/api/v1/apps/%s", appID)
 	req := restClient.R().SetBody("").SetResult(&OktaApplication{})
 
-	resp, err := req.Get(url)
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
 	if err != nil {
 		return nil, err
 	}
@@ -100,8 +136,43 @@ func (o *Okta) GetApplication(appID string) (*OktaApplication, error) {
 	return response, nil
 }
 
-func (o *Okta) CreateAwsApplication(name string, providerArn string) (*OktaApplication, error) {
-	application := OktaApplicationContents{
+// FindApplicationByLabel returns the app with exactly the given label, or
+// nil when there is none. Labels aren't unique in Okta, so more than one
+// match is an error.
+func (o *Okta) FindApplicationByLabel(ctx context.Context, label string) (*OktaApplication, error) {
+	restClient := o.GetRestClient()
+
+	url := "/api/v1/apps"
+	req := restClient.R().SetBody("").
+		SetQueryParams(map[string]string{"q": label, "limit": "200"}).
+		SetResult(&[]OktaApplication{})
+
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []OktaApplication{}
+	for _, app := range *resp.Result().(*[]OktaApplication) {
+		if app.Label == label {
+			matches = append(matches, app)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return &matches[0], nil
+	}
+
+	return nil, fmt.Errorf("Found %d applications labelled %q, use the application ID instead", len(matches), label)
+}
+
+// NewAwsApplication returns the settings for a new AWS federation app, with
+// the roles discovered from groups named aws_<account id>_<role>.
+func NewAwsApplication(name string, providerArn string) OktaApplicationContents {
+	return OktaApplicationContents{
 		Name:       "amazon_aws",
 		Label:      name,
 		SignOnMode: "SAML_2_0",
@@ -109,7 +180,7 @@ func (o *Okta) CreateAwsApplication(name string, providerArn string) (*OktaAppli
 			App: OktaApplicationAppSettings{
 				AwsEnvironmentType:  "aws.amazon",
 				LoginURL:            "https://console.aws.amazon.com/ec2/home",
-				JoinAllRoles:        false,
+				JoinAllRoles:        Bool(false),
 				SessionDuration:     43200,
 				IdentityProviderArn: providerArn,
 				GroupFilter:         "aws_(?{{accountid}}\\d+)_(?{{role}}[a-zA-Z0-9+=,.@\\-_]+)",
@@ -117,11 +188,30 @@ func (o *Okta) CreateAwsApplication(name string, providerArn string) (*OktaAppli
 			},
 		},
 	}
+}
 
-	return o.CreateApplication(application)
+// NewAwsIdentityCenterApplication returns the settings for a new AWS IAM
+// Identity Center app, which sends assertions to the Identity Center
+// instance's ACS URL with its issuer as the audience.
+func NewAwsIdentityCenterApplication(name string, acsURL string, entityID string) OktaApplicationContents {
+	return OktaApplicationContents{
+		Name:       "amazon_aws_sso",
+		Label:      name,
+		SignOnMode: "SAML_2_0",
+		Settings: OktaApplicationSettings{
+			App: OktaApplicationAppSettings{
+				AcsURL:   acsURL,
+				EntityID: entityID,
+			},
+		},
+	}
 }
 
-func (o *Okta) CreateApplication(application OktaApplicationContents) (*OktaApplication, error) {
+func (o *Okta) CreateAwsApplication(ctx context.Context, name string, providerArn string) (*OktaApplication, error) {
+	return o.CreateApplication(ctx, NewAwsApplication(name, providerArn))
+}
+
+func (o *Okta) CreateApplication(ctx context.Context, application OktaApplicationContents) (*OktaApplication, error) {
 	var result *OktaApplication
 	restClient := o.GetRestClient()
 
@@ -133,7 +223,7 @@ func (o *Okta) CreateApplication(application OktaApplicationContents) (*OktaAppl
 	url := "/api/v1/apps"
 	req := restClient.R().SetBody(string(body)).SetResult(&OktaApplication{})
 
-	resp, err := req.Post(url)
+	resp, err := o.execute(ctx, req, resty.MethodPost, url)
 	if err != nil {
 		return result, err
 	}
@@ -142,13 +232,13 @@ func (o *Okta) CreateApplication(application OktaApplicationContents) (*OktaAppl
 	return response, nil
 }
 
-func (o *Okta) DeactivateApplication(appID string) error {
+func (o *Okta) DeactivateApplication(ctx context.Context, appID string) error {
 	restClient := o.GetRestClient()
 
 	url := fmt.Sprintf("/api/v1/apps/%s/lifecycle/deactivate", appID)
 	req := restClient.R().SetBody("")
 
-	_, err := req.Post(url)
+	_, err := o.execute(ctx, req, resty.MethodPost, url)
 	if err != nil {
 		return err
 	}
@@ -156,13 +246,13 @@ func (o *Okta) DeactivateApplication(appID string) error {
 	return nil
 }
 
-func (o *Okta) DeleteApplication(appID string) error {
+func (o *Okta) DeleteApplication(ctx context.Context, appID string) error {
 	restClient := o.GetRestClient()
 
-	url := fmt.Sprintf("api/v1/apps/%s", appID)
+	url := fmt.Sprintf("/api/v1/apps/%s", appID)
 	req := restClient.R().SetBody("")
 
-	_, err := req.Delete(url)
+	_, err := o.execute(ctx, req, resty.MethodDelete, url)
 	if err != nil {
 		return err
 	}
@@ -170,14 +260,14 @@ func (o *Okta) DeleteApplication(appID string) error {
 	return nil
 }
 
-func (o *Okta) GetSAMLMetadata(appID string, keyID string) (string, error) {
+func (o *Okta) GetSAMLMetadata(ctx context.Context, appID string, keyID string) (string, error) {
 	restClient := o.GetRestClient()
 
 	url := fmt.Sprintf("/api/v1/apps/%s/sso/saml/metadata?kid=%s", appID, keyID)
 	req := restClient.R().SetBody("")
 	req.SetHeader("Accept", "application/xml")
 
-	resp, err := req.Get(url)
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
 	if err != nil {
 		return "", err
 	}
@@ -191,8 +281,10 @@ func (o *Okta) GetSAMLMetadata(appID string, keyID string) (string, error) {
 	return response, nil
 }
 
-func (o *Okta) UpdateAwsApplication(appId string, name string, providerArn string) (*OktaApplication, error) {
-	application := OktaApplicationContents{
+// NewAwsApplicationUpdate returns the settings to update an AWS federation
+// app with. Settings left out are kept as they are in Okta.
+func NewAwsApplicationUpdate(appId string, name string, providerArn string) OktaApplicationContents {
+	return OktaApplicationContents{
 		ID:         appId,
 		Name:       "amazon_aws",
 		Label:      name,
@@ -201,21 +293,39 @@ func (o *Okta) UpdateAwsApplication(appId string, name string, providerArn strin
 			App: OktaApplicationAppSettings{
 				AwsEnvironmentType:  "aws.amazon",
 				LoginURL:            "https://console.aws.amazon.com/ec2/home",
-				JoinAllRoles:        false,
-				SessionDuration:     43200,
+				JoinAllRoles:        Bool(false),
 				IdentityProviderArn: providerArn,
 			},
 		},
 	}
+}
 
-	return o.UpdateApplication(application)
+func (o *Okta) UpdateAwsApplication(ctx context.Context, appId string, name string, providerArn string) (*OktaApplication, error) {
+	return o.UpdateApplication(ctx, NewAwsApplicationUpdate(appId, name, providerArn))
 }
 
-func (o *Okta) UpdateApplication(application OktaApplicationContents) (*OktaApplication, error) {
+// UpdateApplication merges the application into the app as it currently is
+// in Okta, so settings it leaves out keep their values rather than being
+// cleared.
+func (o *Okta) UpdateApplication(ctx context.Context, application OktaApplicationContents) (*OktaApplication, error) {
 	var result *OktaApplication
 	restClient := o.GetRestClient()
 
-	body, err := json.Marshal(application)
+	current, err := o.GetApplication(ctx, application.ID)
+	if err != nil {
+		return result, err
+	}
+
+	if current == nil {
+		return result, fmt.Errorf("Could not update application %s, it was not found", application.ID)
+	}
+
+	merged, err := MergeApplication(current.OktaApplicationContents, application)
+	if err != nil {
+		return result, err
+	}
+
+	body, err := json.Marshal(merged)
 	if err != nil {
 		return result, err
 	}
@@ -223,7 +333,7 @@ func (o *Okta) UpdateApplication(application OktaApplicationContents) (*OktaAppl
 	url := fmt.Sprintf("/api/v1/apps/%s", application.ID)
 	req := restClient.R().SetBody(string(body)).SetResult(&OktaApplication{})
 
-	resp, err := req.Put(url)
+	resp, err := o.execute(ctx, req, resty.MethodPut, url)
 	if err != nil {
 		return result, err
 	}
@@ -235,17 +345,26 @@ func (o *Okta) UpdateApplication(application OktaApplicationContents) (*OktaAppl
 func (okta *Okta) SetRestClient(rest *resty.Client) {
 	rest.SetHostURL(okta.HostURL)
 
-	// Retry
-	rest.SetRetryCount(okta.RetryMaximum)
-	rest.SetRetryWaitTime(RetryWaitTimeInSeconds)
-	rest.SetRetryMaxWaitTime(MaximumRetryWaitTimeInSeconds)
-	rest.AddRetryCondition(func(r *resty.Response, err error) bool {
-		switch code := r.StatusCode(); code {
-		case http.StatusTooManyRequests:
-			return true
-		default:
-			return false
+	// Tracing
+	rest.OnBeforeRequest(func(c *resty.Client, r *resty.Request) error {
+		if body, ok := r.Body.(string); ok {
+			traceBody("request", "application/json", []byte(body))
 		}
+		return nil
+	})
+	rest.OnAfterResponse(func(c *resty.Client, r *resty.Response) error {
+		traceRequest(r.Request.Method, r.Request.RawRequest.URL, r.StatusCode(), r.Time(), r.Header(), nil)
+		traceBody("response", r.Header().Get("Content-Type"), r.Body())
+		return nil
+	})
+
+	// Rate limits
+	limits := newRateLimits()
+	okta.rateLimits = limits
+	okta.limiter = newRequestLimiter(okta.MaxConcurrentRequests, okta.MaxConcurrentRequestsPerBucket)
+	rest.OnAfterResponse(func(c *resty.Client, r *resty.Response) error {
+		limits.update(rateLimitBucket(r.Request.Method, r.Request.URL), r.Header())
+		return nil
 	})
 
 	// Error handling
@@ -255,6 +374,16 @@ func (okta *Okta) SetRestClient(rest *resty.Client) {
 			return nil
 		}
 
+		if status == http.StatusUnauthorized && okta.OAuth != nil {
+			okta.OAuth.Invalidate()
+		}
+
+		if status == http.StatusForbidden && okta.OAuth != nil {
+			if scope := requiredScope(r.Request.Method, r.Request.URL); scope != "" {
+				return fmt.Errorf(`Response not successful: Received status code %d, %s %s needs the %s scope. Check it is in the provider's scopes and granted to the service app. Response: %s`, status, r.Request.Method, r.Request.URL, scope, r.String())
+			}
+		}
+
 		if (status < 200) || (status >= 400) {
 			rateLimit, err := strconv.Atoi(r.Header().Get("x-rate-limit-remaining"))
 
@@ -268,27 +397,115 @@ func (okta *Okta) SetRestClient(rest *resty.Client) {
 		return nil
 	})
 
-	sign, _ := NewHTTPSignature(okta.APIKey)
-	rest.SetHeaders(sign)
+	if okta.OAuth != nil {
+		rest.SetHeader("Accept", "application/json")
+		rest.SetHeader("Content-Type", "application/json")
+	} else {
+		sign, _ := NewHTTPSignature(okta.APIKey)
+		rest.SetHeaders(sign)
+	}
 
 	okta.RestClient = rest
 }
 
-func (okta *Okta) GetRestClient() *resty.Client {
-	if okta.RestClient == nil {
-		rest := resty.New()
-		okta.SetRestClient(rest)
+// execute sends the request, retrying it as described by the retry policy.
+// Each attempt waits for the endpoint's rate limit to allow it, and for a
+// free slot under the concurrency limits.
+//
+// With OAuth, each attempt carries the current access token, unless the
+// request sets its own Authorization. A token Okta rejects before it was due
+// to expire is invalidated, and the request is sent once more with a new one.
+func (o *Okta) execute(ctx context.Context, req *resty.Request, method string, url string) (*resty.Response, error) {
+	retryable := func(status int, err error) bool {
+		return o.RetryPolicy.ShouldRetryRequest(method, status, err)
+	}
+
+	if o.OAuth == nil || req.Header.Get("Authorization") != "" {
+		return o.send(ctx, req, method, url, nil, retryable)
+	}
+
+	resp, err := o.send(ctx, req, method, url, o.authorize, retryable)
+	if resp != nil && resp.StatusCode() == http.StatusUnauthorized {
+		log.Printf("[DEBUG] Okta rejected the access token for %s %s, retrying with a new one", method, url)
+		resp, err = o.send(ctx, req, method, url, o.authorize, retryable)
+	}
+
+	return resp, err
+}
+
+// authorize sets the OAuth access token on the request. A new token is asked
+// for like any other request, waiting for the rate limit and a free slot,
+// and retried whatever its method, as asking for one changes nothing.
+func (o *Okta) authorize(ctx context.Context, req *resty.Request) error {
+	token, err := o.OAuth.accessToken(o.HostURL, func(tokenReq *resty.Request) (*resty.Response, error) {
+		return o.send(ctx, tokenReq, resty.MethodPost, OAuthTokenPath, nil, o.RetryPolicy.ShouldRetry)
+	})
+	if err != nil {
+		return err
 	}
+
+	sign, _ := NewBearerSignature(token)
+	req.SetHeader("Authorization", sign["Authorization"])
+	return nil
+}
+
+// send makes the attempts at a request for execute. Before each one,
+// prepare, when given, updates the request.
+func (o *Okta) send(ctx context.Context, req *resty.Request, method string, url string, prepare func(context.Context, *resty.Request) error, retryable func(int, error) bool) (*resty.Response, error) {
+	var resp *resty.Response
+	bucket := rateLimitBucket(method, url)
+
+	err := o.RetryPolicy.Do(ctx, func(attempt int) (bool, error) {
+		if prepare != nil {
+			if err := prepare(ctx, req); err != nil {
+				return false, err
+			}
+		}
+
+		if err := o.rateLimits.wait(ctx, bucket); err != nil {
+			return false, err
+		}
+
+		release, err := o.limiter.acquire(ctx, bucket)
+		if err != nil {
+			return false, err
+		}
+
+		resp, err = req.SetContext(ctx).Execute(method, url)
+		release()
+		if resp == nil {
+			return false, err
+		}
+
+		if err != nil && resp.RawResponse == nil && resp.Request.RawRequest != nil {
+			traceRequest(method, resp.Request.RawRequest.URL, 0, time.Since(resp.Request.Time), nil, err)
+		}
+
+		return retryable(resp.StatusCode(), err), err
+	})
+
+	return resp, err
+}
+
+// GetRestClient returns the client's REST client, creating it on first use.
+// It is shared by every request, so the connection pool and rate limits are
+// too, and is safe to call from parallel resource operations.
+func (okta *Okta) GetRestClient() *resty.Client {
+	okta.restOnce.Do(func() {
+		if okta.RestClient == nil {
+			okta.SetRestClient(resty.New())
+		}
+	})
 	return okta.RestClient
 }
 
-func (o *Okta) GetUserIDByEmail(user string, domain string) (string, error) {
+func (o *Okta) GetUserIDByEmail(ctx context.Context, user string, domain string) (string, error) {
 	restClient := o.GetRestClient()
 	url := fmt.Sprintf("/api/v1/users?q=%s", user)
 
 	req := restClient.R().SetBody("").SetResult(&[]OktaUser{})
 
-	resp, err := req.Get(url)
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
 	if err != nil {
 		return "", err
 	}
@@ -312,13 +529,16 @@ func (o *Okta) GetUserIDByEmail(user string, domain string) (string, error) {
 	return "", nil
 }
 
-func (o *Okta) RemoveAppMember(appId string, userId string) error {
+// RemoveAppMember unassigns the user from the app, deactivating their
+// account in it when the app deprovisions users. Okta emails the user about
+// it when sendEmail is set.
+func (o *Okta) RemoveAppMember(ctx context.Context, appId string, userId string, sendEmail bool) error {
 	restClient := o.GetRestClient()
 
-	url := fmt.Sprintf("/api/v1/apps/%s/users/%s", appId, userId)
+	url := fmt.Sprintf("/api/v1/apps/%s/users/%s?sendEmail=%t", appId, userId, sendEmail)
 	req := restClient.R().SetBody("")
 
-	_, err := req.Delete(url)
+	_, err := o.execute(ctx, req, resty.MethodDelete, url)
 	if err != nil {
 		return err
 	}
@@ -326,13 +546,13 @@ func (o *Okta) RemoveAppMember(appId string, userId string) error {
 	return nil
 }
 
-func (o *Okta) GetAppMember(appId string, userId string) (*OktaUser, error) {
+func (o *Okta) GetAppMember(ctx context.Context, appId string, userId string) (*OktaUser, error) {
 	restClient := o.GetRestClient()
 
 	url := fmt.Sprintf("/api/v1/apps/%s/users/%s", appId, userId)
 	req := restClient.R().SetBody("").SetResult(&OktaUser{})
 
-	resp, err := req.Get(url)
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
 	if err != nil {
 		return nil, err
 	}
@@ -350,17 +570,76 @@ func (o *Okta) GetAppMember(appId string, userId string) (*OktaUser, error) {
 	return response, nil
 }
 
-func (o *Okta) ListAppMembers(appId string) ([]OktaUser, error) {
-	// I've set the results per page to 500, but if the organization needs
-	// more than that this will need pagination enabled.
-	// TODO: Add pagination for listing app members
+// ListAppMembers returns every user assigned to the app, following the
+// pages of results.
+func (o *Okta) ListAppMembers(ctx context.Context, appId string) ([]OktaUser, error) {
 	resultsPerPage := 500
 	restClient := o.GetRestClient()
 
+	members := []OktaUser{}
 	url := fmt.Sprintf("/api/v1/apps/%s/users?limit=%d", appId, resultsPerPage)
-	req := restClient.R().SetBody("").SetResult([]OktaUser{})
+	for url != "" {
+		req := restClient.R().SetBody("").SetResult([]OktaUser{})
 
-	resp, err := req.Get(url)
+		resp, err := o.execute(ctx, req, resty.MethodGet, url)
+		if err != nil {
+			return nil, err
+		}
+
+		status := resp.StatusCode()
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+
+		response := resp.Result().(*[]OktaUser)
+		if response != nil {
+			members = append(members, *response...)
+		}
+
+		url = nextPageURL(resp.Header())
+	}
+
+	return members, nil
+}
+
+// nextPageURL returns the URL of the next page of results from the Link
+// header, or an empty string on the last page.
+func nextPageURL(header http.Header) string {
+	for _, link := range header["Link"] {
+		for _, value := range strings.Split(link, ",") {
+			parts := strings.Split(value, ";")
+			if len(parts) < 2 || strings.TrimSpace(parts[1]) != `rel="next"` {
+				continue
+			}
+
+			return strings.Trim(strings.TrimSpace(parts[0]), "<>")
+		}
+	}
+
+	return ""
+}
+
+// GetAppRoles returns the roles users can be given in the app, as listed
+// by its user schema. For AWS apps these are the roles discovered in the
+// accounts, available as samlRoles and role.
+func (o *Okta) GetAppRoles(ctx context.Context, appId string) ([]string, error) {
+	schema, err := o.GetAppUserSchema(ctx, appId)
+	if err != nil || schema == nil {
+		return nil, err
+	}
+
+	return schema.Roles(), nil
+}
+
+// GetAppUserSchema returns the schema of the profile users have in the app,
+// or nil when the app doesn't exist.
+func (o *Okta) GetAppUserSchema(ctx context.Context, appId string) (*OktaAppUserSchema, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/meta/schemas/apps/%s/default", appId)
+	req := restClient.R().SetBody("").SetResult(&OktaAppUserSchema{})
+
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
 	if err != nil {
 		return nil, err
 	}
@@ -370,35 +649,130 @@ func (o *Okta) ListAppMembers(appId string) ([]OktaUser, error) {
 		return nil, nil
 	}
 
-	response := resp.Result().(*[]OktaUser)
-	if response == nil {
-		return nil, nil
+	return resp.Result().(*OktaAppUserSchema), nil
+}
+
+// Roles returns the distinct values of the samlRoles and role properties,
+// sorted.
+func (s *OktaAppUserSchema) Roles() []string {
+	seen := map[string]bool{}
+	roles := []string{}
+
+	for _, definition := range []OktaSchemaDefinition{s.Definitions.Base, s.Definitions.Custom} {
+		values := []string{}
+		if property, ok := definition.Properties["samlRoles"]; ok && property.Items != nil {
+			values = append(values, property.Items.values()...)
+		}
+		if property, ok := definition.Properties["role"]; ok {
+			values = append(values, property.values()...)
+		}
+
+		for _, value := range values {
+			if value != "" && !seen[value] {
+				seen[value] = true
+				roles = append(roles, value)
+			}
+		}
+	}
+
+	sort.Strings(roles)
+	return roles
+}
+
+var (
+	iamRoleArn       = regexp.MustCompile(`arn:[a-z-]+:iam::(\d{12}):role/([\w+=,.@/-]+)`)
+	accountRoleTitle = regexp.MustCompile(`^(\d{12})\s*[-:]\s*(.+)$`)
+)
+
+// AccountRoles returns the SAML roles of each AWS account, sorted. Roles are
+// told apart by account when their value is the role's ARN, or their title
+// starts with the account ID. The others, such as the roles of an app that
+// joins the roles of all its accounts, are listed under an empty account.
+func (s *OktaAppUserSchema) AccountRoles() map[string][]string {
+	seen := map[string]bool{}
+	accounts := map[string][]string{}
+
+	for _, definition := range []OktaSchemaDefinition{s.Definitions.Base, s.Definitions.Custom} {
+		property, ok := definition.Properties["samlRoles"]
+		if !ok || property.Items == nil {
+			continue
+		}
+
+		for _, option := range property.Items.options() {
+			account, role := "", option.Const
+			if parts := iamRoleArn.FindStringSubmatch(option.Const); parts != nil {
+				account, role = parts[1], parts[2]
+			} else if parts := accountRoleTitle.FindStringSubmatch(option.Title); parts != nil {
+				account, role = parts[1], parts[2]
+			}
+
+			if role == "" || seen[account+"/"+role] {
+				continue
+			}
+			seen[account+"/"+role] = true
+			accounts[account] = append(accounts[account], role)
+		}
+	}
+
+	for _, roles := range accounts {
+		sort.Strings(roles)
+	}
+	return accounts
+}
+
+// AddAppMember assigns the user to the app with the given role and SAML
+// roles.
+func (o *Okta) AddAppMember(ctx context.Context, appId string, userId string, role string, roles []string) (*OktaUser, error) {
+	assignment, err := NewAppUserAssignment(userId, map[string]interface{}{
+		"role":      role,
+		"samlRoles": roles,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return *response, nil
+	return o.AssignAppMember(ctx, appId, assignment)
 }
 
-func (o *Okta) AddAppMember(appId string, userId string, role string, roles []string) (*OktaUser, error) {
+// AssignAppMember assigns the user to the app, or updates their assignment
+// when they already have one.
+func (o *Okta) AssignAppMember(ctx context.Context, appId string, assignment OktaAppUserAssignment) (*OktaUser, error) {
 	restClient := o.GetRestClient()
 
 	url := fmt.Sprintf("/api/v1/apps/%s/users", appId)
-	payload := map[string]interface{}{
-		"id":    userId,
-		"scope": "USER",
-		"profile": map[string]interface{}{
-			"role":      role,
-			"samlRoles": roles,
-		},
+
+	body, err := json.Marshal(assignment)
+	if err != nil {
+		return nil, err
+	}
+
+	req := restClient.R().SetBody(string(body)).SetResult(&OktaUser{})
+
+	resp, err := o.execute(ctx, req, resty.MethodPost, url)
+	if err != nil {
+		return nil, err
 	}
 
-	body, err := json.Marshal(payload)
+	result := resp.Result().(*OktaUser)
+	return result, nil
+}
+
+// SetAppMemberScope changes the scope of the user's assignment to the app.
+// Okta only converts an assignment to GROUP when the user is in a group
+// assigned to the app, and the group's profile then applies.
+func (o *Okta) SetAppMemberScope(ctx context.Context, appId string, userId string, scope string) (*OktaUser, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/users/%s", appId, userId)
+
+	body, err := json.Marshal(map[string]string{"scope": scope})
 	if err != nil {
 		return nil, err
 	}
 
 	req := restClient.R().SetBody(string(body)).SetResult(&OktaUser{})
 
-	resp, err := req.Post(url)
+	resp, err := o.execute(ctx, req, resty.MethodPost, url)
 	if err != nil {
 		return nil, err
 	}
@@ -406,3 +780,61 @@ func (o *Okta) AddAppMember(appId string, userId string, role string, roles []st
 	result := resp.Result().(*OktaUser)
 	return result, nil
 }
+
+// ListAppMemberGroups returns the groups the user is in which are assigned
+// to the app, those the user's access could also come from.
+func (o *Okta) ListAppMemberGroups(ctx context.Context, appId string, userId string) ([]OktaGroup, error) {
+	appGroups, err := o.listGroups(ctx, fmt.Sprintf("/api/v1/apps/%s/groups?limit=200", appId))
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := map[string]bool{}
+	for _, group := range appGroups {
+		assigned[group.ID] = true
+	}
+
+	userGroups, err := o.listGroups(ctx, fmt.Sprintf("/api/v1/users/%s/groups", userId))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []OktaGroup{}
+	for _, group := range userGroups {
+		if assigned[group.ID] {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+// listGroups returns the groups listed at the URL, following the pages of
+// results. Only the ID is set on the groups assigned to an app.
+func (o *Okta) listGroups(ctx context.Context, url string) ([]OktaGroup, error) {
+	restClient := o.GetRestClient()
+
+	groups := []OktaGroup{}
+	for url != "" {
+		req := restClient.R().SetBody("").SetResult([]OktaGroup{})
+
+		resp, err := o.execute(ctx, req, resty.MethodGet, url)
+		if err != nil {
+			return nil, err
+		}
+
+		status := resp.StatusCode()
+		if status == http.StatusNotFound {
+			return nil, nil
+		}
+
+		response := resp.Result().(*[]OktaGroup)
+		if response != nil {
+			groups = append(groups, *response...)
+		}
+
+		url = nextPageURL(resp.Header())
+	}
+
+	return groups, nil
+}
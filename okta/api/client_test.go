@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOkta_awsApplicationCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "aws_application")
+	ctx := context.Background()
+
+	created, err := client.CreateAwsApplication(ctx, "example-aws", "arn:aws:iam::123456789012:saml-provider/OKTA")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if created.ID == "" || created.SigningKeyID() == "" {
+		t.Fatalf("expected the app ID and signing key ID, got %+v", created)
+	}
+
+	if created.Settings.App.IdentityProviderArn != "arn:aws:iam::123456789012:saml-provider/OKTA" {
+		t.Fatalf("unexpected identity provider ARN %q", created.Settings.App.IdentityProviderArn)
+	}
+
+	read, err := client.GetApplication(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if read == nil || read.Label != "example-aws" || read.SignOnMode != "SAML_2_0" {
+		t.Fatalf("unexpected app %+v", read)
+	}
+
+	metadata, err := client.GetSAMLMetadata(ctx, created.ID, created.SigningKeyID())
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !strings.Contains(metadata, "md:EntityDescriptor") {
+		t.Fatalf("expected SAML metadata, got %q", metadata)
+	}
+
+	updated, err := client.UpdateAwsApplication(ctx, created.ID, "example-aws-renamed", "arn:aws:iam::123456789012:saml-provider/OKTA-2")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if updated.Label != "example-aws-renamed" || updated.Settings.App.IdentityProviderArn != "arn:aws:iam::123456789012:saml-provider/OKTA-2" {
+		t.Fatalf("unexpected app %+v", updated)
+	}
+
+	if err := client.DeactivateApplication(ctx, created.ID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.DeleteApplication(ctx, created.ID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	deleted, err := client.GetApplication(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if deleted != nil {
+		t.Fatalf("expected no app once deleted, got %+v", deleted)
+	}
+
+	finishCassette(t, recorder)
+}
+
+func TestOkta_appMembersCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "app_members")
+	ctx := context.Background()
+	appID := "0oa1b2c3d4e5f6g7h8i9"
+
+	userID, err := client.GetUserIDByEmail(ctx, "jdoe", "example.com")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if userID == "" {
+		t.Fatalf("expected to find the user")
+	}
+
+	added, err := client.AddAppMember(ctx, appID, userID, "Developer", []string{"Developer", "ReadOnly"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if added.ID != userID || added.Profile.Role != "Developer" || len(added.Profile.SamlRoles) != 2 {
+		t.Fatalf("unexpected member %+v", added)
+	}
+
+	member, err := client.GetAppMember(ctx, appID, userID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if member == nil || member.Status != "ACTIVE" || member.Scope != "USER" || member.SyncState != "DISABLED" {
+		t.Fatalf("unexpected member %+v", member)
+	}
+
+	members, err := client.ListAppMembers(ctx, appID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(members) != 1 || members[0].ID != userID {
+		t.Fatalf("unexpected members %+v", members)
+	}
+
+	if err := client.RemoveAppMember(ctx, appID, userID, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	finishCassette(t, recorder)
+}
+
+func TestOktaAppUserSchema_Roles(t *testing.T) {
+	body := `{
+		"definitions": {
+			"base": {
+				"properties": {
+					"role": {"type": "string", "enum": ["ReadOnly", "Developer"]},
+					"samlRoles": {"type": "array", "items": {"type": "string", "enum": ["ReadOnly", "Developer", "Admin"]}}
+				}
+			},
+			"custom": {
+				"properties": {
+					"samlRoles": {"type": "array", "items": {"type": "string", "oneOf": [{"const": "Billing", "title": "Billing"}]}}
+				}
+			}
+		}
+	}`
+
+	appSchema := OktaAppUserSchema{}
+	if err := json.Unmarshal([]byte(body), &appSchema); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	roles := appSchema.Roles()
+	if !reflect.DeepEqual(roles, []string{"Admin", "Billing", "Developer", "ReadOnly"}) {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	header := http.Header{}
+	header.Add("Link", `<https://example.okta.com/api/v1/apps/0oa1/users?limit=500>; rel="self"`)
+	header.Add("Link", `<https://example.okta.com/api/v1/apps/0oa1/users?after=00u2&limit=500>; rel="next"`)
+
+	if next := nextPageURL(header); next != "https://example.okta.com/api/v1/apps/0oa1/users?after=00u2&limit=500" {
+		t.Fatalf("unexpected next page %q", next)
+	}
+
+	header.Del("Link")
+	if next := nextPageURL(header); next != "" {
+		t.Fatalf("expected no next page, got %q", next)
+	}
+}
+
+func TestOkta_appMemberGroupsCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "app_member_groups")
+	ctx := context.Background()
+	appID := "0oa1b2c3d4e5f6g7h8i9"
+	userID := "00u1a2b3c4d5e6f7g8h9"
+
+	groups, err := client.ListAppMemberGroups(ctx, appID, userID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(groups) != 1 || groups[0].Profile.Name != "aws_123456789012_ReadOnly" {
+		t.Fatalf("expected only the group assigned to the app, got %+v", groups)
+	}
+
+	member, err := client.SetAppMemberScope(ctx, appID, userID, "GROUP")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if member.Scope != "GROUP" || member.Links.Group == nil || member.Links.Group.Name != "aws_123456789012_ReadOnly" {
+		t.Fatalf("expected the assignment to come from the group, got %+v", member)
+	}
+
+	finishCassette(t, recorder)
+}
+
+func TestOktaAppUserSchema_AccountRoles(t *testing.T) {
+	body := `{
+		"definitions": {
+			"base": {
+				"properties": {
+					"samlRoles": {"type": "array", "items": {"type": "string", "oneOf": [
+						{"const": "arn:aws:iam::123456789012:saml-provider/OKTA,arn:aws:iam::123456789012:role/ReadOnly", "title": "ReadOnly"},
+						{"const": "arn:aws:iam::123456789012:saml-provider/OKTA,arn:aws:iam::123456789012:role/Admin", "title": "Admin"},
+						{"const": "Billing", "title": "567856785678 - Billing"},
+						{"const": "Developer", "title": "Developer"}
+					]}}
+				}
+			}
+		}
+	}`
+
+	appSchema := OktaAppUserSchema{}
+	if err := json.Unmarshal([]byte(body), &appSchema); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := map[string][]string{
+		"123456789012": {"Admin", "ReadOnly"},
+		"567856785678": {"Billing"},
+		"":             {"Developer"},
+	}
+
+	if roles := appSchema.AccountRoles(); !reflect.DeepEqual(roles, expected) {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+}
+
+func TestOkta_scimProvisioningCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "scim_provisioning")
+	ctx := context.Background()
+	appID := "0oa2b3c4d5e6f7g8h9i0"
+
+	connection, err := client.SetProvisioningConnection(ctx, appID, "https://scim.us-east-1.amazonaws.com/abcd1234/scim/v2", "secret")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if connection.Status != "ENABLED" || connection.Profile.Token != "" {
+		t.Fatalf("expected an enabled connection without the token, got %+v", connection)
+	}
+
+	feature, err := client.UpdateProvisioningFeature(ctx, appID, OktaProvisioningCapabilities{
+		Create: OktaProvisioningCreate{LifecycleCreate: CapabilityStatus(true)},
+		Update: OktaProvisioningUpdate{
+			LifecycleDeactivate: CapabilityStatus(true),
+			Profile:             CapabilityStatus(false),
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !feature.Capabilities.Create.LifecycleCreate.Enabled() || feature.Capabilities.Update.Profile.Enabled() {
+		t.Fatalf("unexpected capabilities %+v", feature.Capabilities)
+	}
+
+	if _, err := client.GetProvisioningConnection(ctx, appID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.DeactivateProvisioningConnection(ctx, appID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	missing, err := client.GetProvisioningFeature(ctx, "0oa404")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no feature for a missing app, got %+v, %v", missing, err)
+	}
+
+	finishCassette(t, recorder)
+}
+
+func TestOkta_authServersCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "auth_servers")
+	ctx := context.Background()
+
+	server, err := client.CreateAuthServer(ctx, OktaAuthServer{
+		Name:        "internal-apis",
+		Audiences:   []string{"api://internal"},
+		IssuerMode:  "ORG_URL",
+		Credentials: &OktaAuthServerCredentials{Signing: OktaAuthServerSigning{RotationMode: "MANUAL"}},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	signing := server.Credentials.Signing
+	if server.Status != "ACTIVE" || signing.RotationMode != "MANUAL" || signing.NextRotation == nil {
+		t.Fatalf("unexpected authorization server %+v", server)
+	}
+
+	scope, err := client.CreateAuthServerScope(ctx, server.ID, OktaAuthServerScope{
+		Name:            "reports:read",
+		Consent:         "IMPLICIT",
+		MetadataPublish: "NO_CLIENTS",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if scope.ID != "scp1a2b3c4d5e6f7g8h9" {
+		t.Fatalf("unexpected scope %+v", scope)
+	}
+
+	policy, err := client.CreateAuthServerPolicy(ctx, server.ID, OktaAuthServerPolicy{
+		Type:        "OAUTH_AUTHORIZATION_POLICY",
+		Name:        "default",
+		Description: "Every client",
+		Conditions:  OktaAuthServerPolicyConditions{Clients: OktaInclude{Include: []string{"ALL_CLIENTS"}}},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule, err := client.CreateAuthServerPolicyRule(ctx, server.ID, policy.ID, OktaAuthServerPolicyRule{
+		Type: "RESOURCE_ACCESS",
+		Name: "services",
+		Conditions: OktaAuthServerPolicyRuleConditions{
+			People: OktaPeopleCondition{
+				Users:  OktaIncludeExclude{Include: []string{}, Exclude: []string{}},
+				Groups: OktaIncludeExclude{Include: []string{"EVERYONE"}, Exclude: []string{}},
+			},
+			GrantTypes: OktaInclude{Include: []string{"client_credentials"}},
+			Scopes:     OktaInclude{Include: []string{scope.Name}},
+		},
+		Actions: OktaAuthServerPolicyRuleActions{
+			Token: OktaAuthServerTokenActions{AccessTokenLifetimeMinutes: 60, RefreshTokenWindowMinutes: 10080},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if rule.Priority != 1 || rule.Actions.Token.RefreshTokenWindowMinutes != 10080 {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	claim, err := client.GetAuthServerClaim(ctx, server.ID, "ocl0missing0000000000")
+	if err != nil || claim != nil {
+		t.Fatalf("expected no claim, got %+v, %v", claim, err)
+	}
+
+	if err := client.SetAuthServerActive(ctx, server.ID, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.DeleteAuthServer(ctx, server.ID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	finishCassette(t, recorder)
+}
+
+func TestOkta_policiesCassette(t *testing.T) {
+	recorder, client, _ := newCassette(t, "policies")
+	ctx := context.Background()
+
+	policy, err := client.CreatePolicy(ctx, OktaPolicy{
+		Type:        "OKTA_SIGN_ON",
+		Name:        "Contractors",
+		Description: "Contractors sign on",
+		Conditions: &OktaPolicyConditions{
+			People: &OktaPolicyPeopleCondition{Groups: OktaInclude{Include: []string{"00g1contractors"}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if policy.Status != "ACTIVE" || policy.Priority != 1 {
+		t.Fatalf("unexpected policy %+v", policy)
+	}
+
+	rule, err := client.CreatePolicyRule(ctx, policy.ID, OktaPolicyRule{
+		Type: "SIGN_ON",
+		Name: "Office",
+		Conditions: OktaPolicyRuleConditions{
+			People:      &OktaPolicyRulePeopleCondition{Users: &OktaIncludeExclude{Include: []string{}, Exclude: []string{}}},
+			Network:     &OktaNetworkCondition{Connection: "ZONE", Include: []string{"nzo1office"}},
+			AuthContext: &OktaAuthContextCondition{AuthType: "ANY"},
+		},
+		Actions: OktaPolicyRuleActions{
+			SignOn: &OktaSignOnAction{
+				Access:           "ALLOW",
+				RequireFactor:    true,
+				FactorPromptMode: "SESSION",
+				FactorLifetime:   15,
+				Session:          OktaSignOnSession{MaxSessionIdleMinutes: 120},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	signOn := rule.Actions.SignOn
+	if rule.Conditions.Network.Include[0] != "nzo1office" || signOn == nil || signOn.FactorLifetime != 15 || signOn.Session.MaxSessionIdleMinutes != 120 {
+		t.Fatalf("unexpected rule %+v", rule)
+	}
+
+	missing, err := client.GetPolicyRule(ctx, policy.ID, "0pr0missing0000000000")
+	if err != nil || missing != nil {
+		t.Fatalf("expected no rule, got %+v, %v", missing, err)
+	}
+
+	if err := client.SetPolicyRuleActive(ctx, policy.ID, rule.ID, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.DeletePolicyRule(ctx, policy.ID, rule.ID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.SetPolicyActive(ctx, policy.ID, false); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := client.DeletePolicy(ctx, policy.ID); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	finishCassette(t, recorder)
+}
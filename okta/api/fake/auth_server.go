@@ -0,0 +1,533 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+// AuthServer is an authorization server with the scopes, claims, policies
+// and rules in it. Rules are keyed by policy ID.
+type AuthServer struct {
+	api.OktaAuthServer
+	Scopes   map[string]*api.OktaAuthServerScope
+	Claims   map[string]*api.OktaAuthServerClaim
+	Policies map[string]*api.OktaAuthServerPolicy
+	Rules    map[string]map[string]*api.OktaAuthServerPolicyRule
+}
+
+var errNotFound = fmt.Errorf("Response not successful: Received status code 404")
+
+// reorder gives the item the priority, clamped to those in use, and moves
+// the others down to make room the same as Okta does. A priority of 0 puts
+// the item last.
+func reorder(priorities map[string]*int, id string, priority int) {
+	others := []string{}
+	for other := range priorities {
+		if other != id {
+			others = append(others, other)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool {
+		return *priorities[others[i]] < *priorities[others[j]]
+	})
+
+	if priority < 1 || priority > len(others)+1 {
+		priority = len(others) + 1
+	}
+
+	ordered := append([]string{}, others[:priority-1]...)
+	ordered = append(ordered, id)
+	ordered = append(ordered, others[priority-1:]...)
+
+	for i, item := range ordered {
+		*priorities[item] = i + 1
+	}
+}
+
+func (f *Okta) CreateAuthServer(ctx context.Context, server api.OktaAuthServer) (*api.OktaAuthServer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateAuthServer"); err != nil {
+		return nil, err
+	}
+
+	lastRotated := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextRotation := lastRotated.AddDate(0, 3, 0)
+
+	server.ID = f.newID("aus")
+	server.Issuer = "https://fake.okta.com/oauth2/" + server.ID
+	server.Status = "ACTIVE"
+	if server.IssuerMode == "" {
+		server.IssuerMode = "ORG_URL"
+	}
+
+	signing := api.OktaAuthServerSigning{RotationMode: "AUTO"}
+	if server.Credentials != nil && server.Credentials.Signing.RotationMode != "" {
+		signing.RotationMode = server.Credentials.Signing.RotationMode
+	}
+	signing.KeyID = f.newID("kid")
+	signing.LastRotated = &lastRotated
+	signing.NextRotation = &nextRotation
+	server.Credentials = &api.OktaAuthServerCredentials{Signing: signing}
+
+	f.AuthServers[server.ID] = &AuthServer{
+		OktaAuthServer: server,
+		Scopes:         map[string]*api.OktaAuthServerScope{},
+		Claims:         map[string]*api.OktaAuthServerClaim{},
+		Policies:       map[string]*api.OktaAuthServerPolicy{},
+		Rules:          map[string]map[string]*api.OktaAuthServerPolicyRule{},
+	}
+
+	result := server
+	return &result, nil
+}
+
+func (f *Okta) GetAuthServer(ctx context.Context, serverID string) (*api.OktaAuthServer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAuthServer"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return nil, nil
+	}
+
+	result := server.OktaAuthServer
+	return &result, nil
+}
+
+// UpdateAuthServer keeps the server's keys, only the rotation mode can be
+// changed.
+func (f *Okta) UpdateAuthServer(ctx context.Context, update api.OktaAuthServer) (*api.OktaAuthServer, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAuthServer"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[update.ID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	signing := server.Credentials.Signing
+	if update.Credentials != nil && update.Credentials.Signing.RotationMode != "" {
+		signing.RotationMode = update.Credentials.Signing.RotationMode
+	}
+
+	update.Issuer = server.Issuer
+	update.Status = server.Status
+	update.Credentials = &api.OktaAuthServerCredentials{Signing: signing}
+	server.OktaAuthServer = update
+
+	result := update
+	return &result, nil
+}
+
+// DeleteAuthServer fails for an active server, the same as Okta does.
+func (f *Okta) DeleteAuthServer(ctx context.Context, serverID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteAuthServer"); err != nil {
+		return err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return errNotFound
+	}
+
+	if server.Status == "ACTIVE" {
+		return fmt.Errorf("Response not successful: Received status code 400. Response: {\"errorCode\":\"E0000147\",\"errorSummary\":\"Cannot delete an active authorization server\"}")
+	}
+
+	delete(f.AuthServers, serverID)
+	return nil
+}
+
+func (f *Okta) SetAuthServerActive(ctx context.Context, serverID string, active bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetAuthServerActive"); err != nil {
+		return err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return errNotFound
+	}
+
+	server.Status = lifecycleStatus(active)
+	return nil
+}
+
+func lifecycleStatus(active bool) string {
+	if active {
+		return "ACTIVE"
+	}
+	return "INACTIVE"
+}
+
+func (f *Okta) CreateAuthServerScope(ctx context.Context, serverID string, scope api.OktaAuthServerScope) (*api.OktaAuthServerScope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateAuthServerScope"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	for _, existing := range server.Scopes {
+		if existing.Name == scope.Name {
+			return nil, fmt.Errorf("Response not successful: Received status code 400. Response: {\"errorCode\":\"E0000001\",\"errorSummary\":\"Api validation failed: name\"}")
+		}
+	}
+
+	scope.ID = f.newID("scp")
+	server.Scopes[scope.ID] = &scope
+
+	result := scope
+	return &result, nil
+}
+
+func (f *Okta) GetAuthServerScope(ctx context.Context, serverID string, scopeID string) (*api.OktaAuthServerScope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAuthServerScope"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Scopes[scopeID] == nil {
+		return nil, nil
+	}
+
+	result := *server.Scopes[scopeID]
+	return &result, nil
+}
+
+func (f *Okta) UpdateAuthServerScope(ctx context.Context, serverID string, scope api.OktaAuthServerScope) (*api.OktaAuthServerScope, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAuthServerScope"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Scopes[scope.ID] == nil {
+		return nil, errNotFound
+	}
+
+	server.Scopes[scope.ID] = &scope
+
+	result := scope
+	return &result, nil
+}
+
+func (f *Okta) DeleteAuthServerScope(ctx context.Context, serverID string, scopeID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteAuthServerScope"); err != nil {
+		return err
+	}
+
+	if server, ok := f.AuthServers[serverID]; ok {
+		delete(server.Scopes, scopeID)
+	}
+	return nil
+}
+
+func (f *Okta) CreateAuthServerClaim(ctx context.Context, serverID string, claim api.OktaAuthServerClaim) (*api.OktaAuthServerClaim, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateAuthServerClaim"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	claim.ID = f.newID("ocl")
+	if claim.Status == "" {
+		claim.Status = "ACTIVE"
+	}
+	server.Claims[claim.ID] = &claim
+
+	result := claim
+	return &result, nil
+}
+
+func (f *Okta) GetAuthServerClaim(ctx context.Context, serverID string, claimID string) (*api.OktaAuthServerClaim, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAuthServerClaim"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Claims[claimID] == nil {
+		return nil, nil
+	}
+
+	result := *server.Claims[claimID]
+	return &result, nil
+}
+
+func (f *Okta) UpdateAuthServerClaim(ctx context.Context, serverID string, claim api.OktaAuthServerClaim) (*api.OktaAuthServerClaim, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAuthServerClaim"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Claims[claim.ID] == nil {
+		return nil, errNotFound
+	}
+
+	server.Claims[claim.ID] = &claim
+
+	result := claim
+	return &result, nil
+}
+
+func (f *Okta) DeleteAuthServerClaim(ctx context.Context, serverID string, claimID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteAuthServerClaim"); err != nil {
+		return err
+	}
+
+	if server, ok := f.AuthServers[serverID]; ok {
+		delete(server.Claims, claimID)
+	}
+	return nil
+}
+
+func (s *AuthServer) policyPriorities() map[string]*int {
+	priorities := map[string]*int{}
+	for id, policy := range s.Policies {
+		priorities[id] = &policy.Priority
+	}
+	return priorities
+}
+
+func (f *Okta) CreateAuthServerPolicy(ctx context.Context, serverID string, policy api.OktaAuthServerPolicy) (*api.OktaAuthServerPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateAuthServerPolicy"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	policy.ID = f.newID("00p")
+	if policy.Status == "" {
+		policy.Status = "ACTIVE"
+	}
+	server.Policies[policy.ID] = &policy
+	server.Rules[policy.ID] = map[string]*api.OktaAuthServerPolicyRule{}
+	reorder(server.policyPriorities(), policy.ID, policy.Priority)
+
+	result := *server.Policies[policy.ID]
+	return &result, nil
+}
+
+func (f *Okta) GetAuthServerPolicy(ctx context.Context, serverID string, policyID string) (*api.OktaAuthServerPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAuthServerPolicy"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Policies[policyID] == nil {
+		return nil, nil
+	}
+
+	result := *server.Policies[policyID]
+	return &result, nil
+}
+
+func (f *Okta) UpdateAuthServerPolicy(ctx context.Context, serverID string, policy api.OktaAuthServerPolicy) (*api.OktaAuthServerPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAuthServerPolicy"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Policies[policy.ID] == nil {
+		return nil, errNotFound
+	}
+
+	policy.Status = server.Policies[policy.ID].Status
+	server.Policies[policy.ID] = &policy
+	reorder(server.policyPriorities(), policy.ID, policy.Priority)
+
+	result := *server.Policies[policy.ID]
+	return &result, nil
+}
+
+func (f *Okta) DeleteAuthServerPolicy(ctx context.Context, serverID string, policyID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteAuthServerPolicy"); err != nil {
+		return err
+	}
+
+	if server, ok := f.AuthServers[serverID]; ok {
+		delete(server.Policies, policyID)
+		delete(server.Rules, policyID)
+	}
+	return nil
+}
+
+func (f *Okta) SetAuthServerPolicyActive(ctx context.Context, serverID string, policyID string, active bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetAuthServerPolicyActive"); err != nil {
+		return err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Policies[policyID] == nil {
+		return errNotFound
+	}
+
+	server.Policies[policyID].Status = lifecycleStatus(active)
+	return nil
+}
+
+func (s *AuthServer) rulePriorities(policyID string) map[string]*int {
+	priorities := map[string]*int{}
+	for id, rule := range s.Rules[policyID] {
+		priorities[id] = &rule.Priority
+	}
+	return priorities
+}
+
+func (f *Okta) CreateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule api.OktaAuthServerPolicyRule) (*api.OktaAuthServerPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateAuthServerPolicyRule"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Policies[policyID] == nil {
+		return nil, errNotFound
+	}
+
+	rule.ID = f.newID("0pr")
+	if rule.Status == "" {
+		rule.Status = "ACTIVE"
+	}
+	server.Rules[policyID][rule.ID] = &rule
+	reorder(server.rulePriorities(policyID), rule.ID, rule.Priority)
+
+	result := *server.Rules[policyID][rule.ID]
+	return &result, nil
+}
+
+func (f *Okta) GetAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) (*api.OktaAuthServerPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAuthServerPolicyRule"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Rules[policyID][ruleID] == nil {
+		return nil, nil
+	}
+
+	result := *server.Rules[policyID][ruleID]
+	return &result, nil
+}
+
+func (f *Okta) UpdateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule api.OktaAuthServerPolicyRule) (*api.OktaAuthServerPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAuthServerPolicyRule"); err != nil {
+		return nil, err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Rules[policyID][rule.ID] == nil {
+		return nil, errNotFound
+	}
+
+	rule.Status = server.Rules[policyID][rule.ID].Status
+	server.Rules[policyID][rule.ID] = &rule
+	reorder(server.rulePriorities(policyID), rule.ID, rule.Priority)
+
+	result := *server.Rules[policyID][rule.ID]
+	return &result, nil
+}
+
+func (f *Okta) DeleteAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteAuthServerPolicyRule"); err != nil {
+		return err
+	}
+
+	if server, ok := f.AuthServers[serverID]; ok {
+		delete(server.Rules[policyID], ruleID)
+	}
+	return nil
+}
+
+func (f *Okta) SetAuthServerPolicyRuleActive(ctx context.Context, serverID string, policyID string, ruleID string, active bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetAuthServerPolicyRuleActive"); err != nil {
+		return err
+	}
+
+	server, ok := f.AuthServers[serverID]
+	if !ok || server.Rules[policyID][ruleID] == nil {
+		return errNotFound
+	}
+
+	server.Rules[policyID][ruleID].Status = lifecycleStatus(active)
+	return nil
+}
@@ -0,0 +1,610 @@
+// Package fake provides in-memory implementations of the okta/api clients,
+// for unit testing resources without a live Okta org.
+package fake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+// Okta is an in-memory implementation of api.OktaAPI. Errors can be injected
+// per operation by setting Errors to the name of the method.
+//
+// Metadata is keyed by app ID, or by app ID and key ID separated by a slash
+// for the metadata of a key other than the one the app signs with. Emailed
+// lists the users sent an email on being removed from an app. Connections
+// keep the token they were set with, which Okta never returns.
+type Okta struct {
+	Applications map[string]*api.OktaApplication
+	Metadata     map[string]string
+	Connections  map[string]*api.OktaProvisioningConnection
+	Features     map[string]*api.OktaProvisioningFeature
+	AuthServers  map[string]*AuthServer
+	Policies     map[string]*Policy
+	Users        map[string]*api.OktaUser
+	Members      map[string]map[string]*api.OktaUser
+	Roles        map[string][]string
+	Schemas      map[string]*api.OktaAppUserSchema
+	Groups       map[string]*api.OktaGroup
+	GroupUsers   map[string][]string
+	AppGroups    map[string][]string
+	Emailed      []string
+	Errors       map[string]error
+
+	mu     sync.Mutex
+	nextID int
+}
+
+var _ api.OktaAPI = &Okta{}
+
+func NewOkta() *Okta {
+	return &Okta{
+		Applications: map[string]*api.OktaApplication{},
+		Metadata:     map[string]string{},
+		Connections:  map[string]*api.OktaProvisioningConnection{},
+		Features:     map[string]*api.OktaProvisioningFeature{},
+		AuthServers:  map[string]*AuthServer{},
+		Policies:     map[string]*Policy{},
+		Users:        map[string]*api.OktaUser{},
+		Members:      map[string]map[string]*api.OktaUser{},
+		Roles:        map[string][]string{},
+		Schemas:      map[string]*api.OktaAppUserSchema{},
+		Groups:       map[string]*api.OktaGroup{},
+		GroupUsers:   map[string][]string{},
+		AppGroups:    map[string][]string{},
+		Errors:       map[string]error{},
+	}
+}
+
+// AddUser adds a user to the org, returning its ID.
+func (f *Okta) AddUser(login string, email string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	user := &api.OktaUser{ID: f.newID("00u"), Status: "ACTIVE"}
+	user.Profile.Login = login
+	user.Profile.Email = email
+	f.Users[user.ID] = user
+
+	return user.ID
+}
+
+// AddGroup adds a group with the users in it to the org, and assigns it to
+// the apps. Users not already in an app are assigned to it through the
+// group. It returns the group's ID.
+func (f *Okta) AddGroup(name string, userIDs []string, appIDs ...string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	group := &api.OktaGroup{ID: f.newID("00g"), Type: "OKTA_GROUP"}
+	group.Profile.Name = name
+	f.Groups[group.ID] = group
+	f.GroupUsers[group.ID] = userIDs
+
+	for _, appID := range appIDs {
+		f.AppGroups[appID] = append(f.AppGroups[appID], group.ID)
+
+		if _, ok := f.Members[appID]; !ok {
+			f.Members[appID] = map[string]*api.OktaUser{}
+		}
+
+		for _, userID := range userIDs {
+			user, ok := f.Users[userID]
+			if _, assigned := f.Members[appID][userID]; !ok || assigned {
+				continue
+			}
+
+			member := &api.OktaUser{ID: user.ID, Status: user.Status, Scope: "GROUP"}
+			member.Credentials.UserName = user.Profile.Login
+			member.Links.Group = &api.OktaLink{Name: name}
+			f.Members[appID][userID] = member
+		}
+	}
+
+	return group.ID
+}
+
+func (f *Okta) newID(prefix string) string {
+	f.nextID++
+	return fmt.Sprintf("%s%d", prefix, f.nextID)
+}
+
+func (f *Okta) fail(method string) error {
+	return f.Errors[method]
+}
+
+func (f *Okta) GetApplication(ctx context.Context, appID string) (*api.OktaApplication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetApplication"); err != nil {
+		return nil, err
+	}
+
+	app, ok := f.Applications[appID]
+	if !ok {
+		return nil, nil
+	}
+
+	result := *app
+	return &result, nil
+}
+
+func (f *Okta) FindApplicationByLabel(ctx context.Context, label string) (*api.OktaApplication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("FindApplicationByLabel"); err != nil {
+		return nil, err
+	}
+
+	var found *api.OktaApplication
+	for _, app := range f.Applications {
+		if app.Label != label {
+			continue
+		}
+
+		if found != nil {
+			return nil, fmt.Errorf("Found more than one application labelled %q", label)
+		}
+
+		result := *app
+		found = &result
+	}
+
+	return found, nil
+}
+
+func (f *Okta) CreateAwsApplication(ctx context.Context, name string, providerArn string) (*api.OktaApplication, error) {
+	if err := f.fail("CreateAwsApplication"); err != nil {
+		return nil, err
+	}
+
+	return f.CreateApplication(ctx, api.NewAwsApplication(name, providerArn))
+}
+
+func (f *Okta) CreateApplication(ctx context.Context, application api.OktaApplicationContents) (*api.OktaApplication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreateApplication"); err != nil {
+		return nil, err
+	}
+
+	app := &api.OktaApplication{OktaApplicationContents: application}
+	app.ID = f.newID("0oa")
+	if app.Credentials == nil {
+		app.Credentials = &api.OktaApplicationCredentials{}
+	}
+	app.Credentials.Signing = &api.OktaApplicationSigning{KeyID: f.newID("kid")}
+	f.Applications[app.ID] = app
+	f.Metadata[app.ID] = samlMetadata(app.ID)
+
+	result := *app
+	return &result, nil
+}
+
+// UpdateAwsApplication sends the same update as the real client, so the
+// settings it leaves out are merged the same way.
+func (f *Okta) UpdateAwsApplication(ctx context.Context, appId string, name string, providerArn string) (*api.OktaApplication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateAwsApplication"); err != nil {
+		return nil, err
+	}
+
+	return f.updateApplication(api.NewAwsApplicationUpdate(appId, name, providerArn))
+}
+
+func (f *Okta) UpdateApplication(ctx context.Context, application api.OktaApplicationContents) (*api.OktaApplication, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateApplication"); err != nil {
+		return nil, err
+	}
+
+	return f.updateApplication(application)
+}
+
+func (f *Okta) updateApplication(application api.OktaApplicationContents) (*api.OktaApplication, error) {
+	app, ok := f.Applications[application.ID]
+	if !ok {
+		return nil, fmt.Errorf("Response not successful: Received status code 404")
+	}
+
+	merged, err := api.MergeApplication(app.OktaApplicationContents, application)
+	if err != nil {
+		return nil, err
+	}
+	app.OktaApplicationContents = merged
+
+	result := *app
+	return &result, nil
+}
+
+func (f *Okta) DeactivateApplication(ctx context.Context, appID string) error {
+	return f.fail("DeactivateApplication")
+}
+
+func (f *Okta) DeleteApplication(ctx context.Context, appID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeleteApplication"); err != nil {
+		return err
+	}
+
+	delete(f.Applications, appID)
+	delete(f.Metadata, appID)
+	delete(f.Connections, appID)
+	delete(f.Features, appID)
+	delete(f.Members, appID)
+	return nil
+}
+
+func (f *Okta) GetSAMLMetadata(ctx context.Context, appID string, keyID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetSAMLMetadata"); err != nil {
+		return "", err
+	}
+
+	if metadata, ok := f.Metadata[appID+"/"+keyID]; ok {
+		return metadata, nil
+	}
+
+	return f.Metadata[appID], nil
+}
+
+func (f *Okta) GetProvisioningConnection(ctx context.Context, appID string) (*api.OktaProvisioningConnection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetProvisioningConnection"); err != nil {
+		return nil, err
+	}
+
+	connection, ok := f.Connections[appID]
+	if !ok {
+		if _, ok := f.Applications[appID]; !ok {
+			return nil, nil
+		}
+		return &api.OktaProvisioningConnection{AuthScheme: "TOKEN", Status: "DISABLED"}, nil
+	}
+
+	result := *connection
+	result.Profile.Token = ""
+	return &result, nil
+}
+
+func (f *Okta) SetProvisioningConnection(ctx context.Context, appID string, baseURL string, token string) (*api.OktaProvisioningConnection, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetProvisioningConnection"); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.Applications[appID]; !ok {
+		return nil, fmt.Errorf("Response not successful: Received status code 404")
+	}
+
+	connection := &api.OktaProvisioningConnection{
+		AuthScheme: "TOKEN",
+		Status:     "ENABLED",
+		BaseURL:    baseURL,
+		Profile:    api.OktaProvisioningConnectionProfile{AuthScheme: "TOKEN", Token: token},
+	}
+	f.Connections[appID] = connection
+
+	result := *connection
+	result.Profile.Token = ""
+	return &result, nil
+}
+
+func (f *Okta) DeactivateProvisioningConnection(ctx context.Context, appID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeactivateProvisioningConnection"); err != nil {
+		return err
+	}
+
+	if connection, ok := f.Connections[appID]; ok {
+		connection.Status = "DISABLED"
+	}
+	delete(f.Features, appID)
+	return nil
+}
+
+func (f *Okta) GetProvisioningFeature(ctx context.Context, appID string) (*api.OktaProvisioningFeature, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetProvisioningFeature"); err != nil {
+		return nil, err
+	}
+
+	feature, ok := f.Features[appID]
+	if !ok {
+		return nil, nil
+	}
+
+	result := *feature
+	return &result, nil
+}
+
+// UpdateProvisioningFeature fails unless the app's connection is active,
+// the same as Okta does.
+func (f *Okta) UpdateProvisioningFeature(ctx context.Context, appID string, capabilities api.OktaProvisioningCapabilities) (*api.OktaProvisioningFeature, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdateProvisioningFeature"); err != nil {
+		return nil, err
+	}
+
+	if connection, ok := f.Connections[appID]; !ok || connection.Status != "ENABLED" {
+		return nil, fmt.Errorf("Response not successful: Received status code 400. Response: {\"errorCode\":\"E0000001\",\"errorSummary\":\"Api validation failed: provisioning is not enabled\"}")
+	}
+
+	feature := &api.OktaProvisioningFeature{
+		Name:         "USER_PROVISIONING",
+		Status:       "ENABLED",
+		Capabilities: capabilities,
+	}
+	f.Features[appID] = feature
+
+	result := *feature
+	return &result, nil
+}
+
+func (f *Okta) GetUserIDByEmail(ctx context.Context, user string, domain string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetUserIDByEmail"); err != nil {
+		return "", err
+	}
+
+	for _, candidate := range f.Users {
+		login := candidate.Profile.Login
+		if !strings.HasPrefix(login, user) && candidate.Profile.Email != user {
+			continue
+		}
+
+		if domain == "" || strings.Contains(login, domain) || strings.HasPrefix(login, "svc_") {
+			return candidate.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (f *Okta) AddAppMember(ctx context.Context, appId string, userId string, role string, roles []string) (*api.OktaUser, error) {
+	if err := f.fail("AddAppMember"); err != nil {
+		return nil, err
+	}
+
+	assignment, err := api.NewAppUserAssignment(userId, map[string]interface{}{
+		"role":      role,
+		"samlRoles": roles,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return f.AssignAppMember(ctx, appId, assignment)
+}
+
+// AssignAppMember merges the assignment's profile into the member's, the
+// same as Okta does.
+func (f *Okta) AssignAppMember(ctx context.Context, appId string, assignment api.OktaAppUserAssignment) (*api.OktaUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("AssignAppMember"); err != nil {
+		return nil, err
+	}
+
+	user, ok := f.Users[assignment.ID]
+	if !ok {
+		return nil, fmt.Errorf("Response not successful: Received status code 404")
+	}
+
+	if _, ok := f.Members[appId]; !ok {
+		f.Members[appId] = map[string]*api.OktaUser{}
+	}
+
+	member := api.OktaUser{ID: user.ID, Status: user.Status}
+	member.Credentials.UserName = user.Profile.Login
+	if current, ok := f.Members[appId][assignment.ID]; ok {
+		member = *current
+	}
+
+	member.Scope = assignment.Scope
+	if member.Scope == "USER" {
+		member.Links.Group = nil
+	}
+	if assignment.Credentials != nil && assignment.Credentials.UserName != "" {
+		member.Credentials.UserName = assignment.Credentials.UserName
+	}
+
+	attributes, err := member.Profile.Attributes()
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range assignment.Profile {
+		if string(value) == "null" {
+			delete(attributes, name)
+		} else {
+			attributes[name] = value
+		}
+	}
+
+	data, err := json.Marshal(attributes)
+	if err != nil {
+		return nil, err
+	}
+	member.Profile = api.OktaUserProfile{}
+	if err := json.Unmarshal(data, &member.Profile); err != nil {
+		return nil, err
+	}
+
+	f.Members[appId][assignment.ID] = &member
+
+	result := member
+	return &result, nil
+}
+
+func (f *Okta) GetAppMember(ctx context.Context, appId string, userId string) (*api.OktaUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAppMember"); err != nil {
+		return nil, err
+	}
+
+	member, ok := f.Members[appId][userId]
+	if !ok {
+		return nil, nil
+	}
+
+	result := *member
+	return &result, nil
+}
+
+func (f *Okta) ListAppMembers(ctx context.Context, appId string) ([]api.OktaUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("ListAppMembers"); err != nil {
+		return nil, err
+	}
+
+	members := []api.OktaUser{}
+	for _, member := range f.Members[appId] {
+		members = append(members, *member)
+	}
+
+	return members, nil
+}
+
+func (f *Okta) RemoveAppMember(ctx context.Context, appId string, userId string, sendEmail bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("RemoveAppMember"); err != nil {
+		return err
+	}
+
+	if sendEmail {
+		f.Emailed = append(f.Emailed, userId)
+	}
+
+	delete(f.Members[appId], userId)
+	return nil
+}
+
+func (f *Okta) GetAppRoles(ctx context.Context, appId string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAppRoles"); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.Applications[appId]; !ok {
+		return nil, nil
+	}
+
+	return append([]string{}, f.Roles[appId]...), nil
+}
+
+// GetAppUserSchema returns the schema set for the app in Schemas, or else
+// one limiting role and samlRoles to the app's Roles.
+func (f *Okta) GetAppUserSchema(ctx context.Context, appId string) (*api.OktaAppUserSchema, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetAppUserSchema"); err != nil {
+		return nil, err
+	}
+
+	if _, ok := f.Applications[appId]; !ok {
+		return nil, nil
+	}
+
+	if schema, ok := f.Schemas[appId]; ok {
+		return schema, nil
+	}
+
+	schema := &api.OktaAppUserSchema{}
+	roles := append([]string{}, f.Roles[appId]...)
+	schema.Definitions.Base.Properties = map[string]api.OktaSchemaProperty{
+		"role":      {Type: "string", Enum: roles},
+		"samlRoles": {Type: "array", Items: &api.OktaSchemaProperty{Type: "string", Enum: roles}},
+	}
+	return schema, nil
+}
+
+func (f *Okta) SetAppMemberScope(ctx context.Context, appId string, userId string, scope string) (*api.OktaUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetAppMemberScope"); err != nil {
+		return nil, err
+	}
+
+	member, ok := f.Members[appId][userId]
+	if !ok {
+		return nil, fmt.Errorf("Response not successful: Received status code 404")
+	}
+
+	if scope == "GROUP" {
+		groups := f.appMemberGroups(appId, userId)
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("Response not successful: Received status code 400. Response: {\"errorCode\":\"E0000001\",\"errorSummary\":\"Api validation failed: scope\"}")
+		}
+
+		member.Links.Group = &api.OktaLink{Name: groups[0].Profile.Name}
+	} else {
+		member.Links.Group = nil
+	}
+	member.Scope = scope
+
+	result := *member
+	return &result, nil
+}
+
+func (f *Okta) ListAppMemberGroups(ctx context.Context, appId string, userId string) ([]api.OktaGroup, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("ListAppMemberGroups"); err != nil {
+		return nil, err
+	}
+
+	return f.appMemberGroups(appId, userId), nil
+}
+
+func (f *Okta) appMemberGroups(appId string, userId string) []api.OktaGroup {
+	groups := []api.OktaGroup{}
+	for _, groupID := range f.AppGroups[appId] {
+		for _, member := range f.GroupUsers[groupID] {
+			if member == userId {
+				groups = append(groups, *f.Groups[groupID])
+				break
+			}
+		}
+	}
+	return groups
+}
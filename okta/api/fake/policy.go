@@ -0,0 +1,230 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+// Policy is a policy with the rules in it.
+type Policy struct {
+	api.OktaPolicy
+	Rules map[string]*api.OktaPolicyRule
+}
+
+// policyPriorities are the priorities of the policies of the type, which
+// are ordered separately from those of other types.
+func (f *Okta) policyPriorities(policyType string) map[string]*int {
+	priorities := map[string]*int{}
+	for id, policy := range f.Policies {
+		if policy.Type == policyType {
+			priorities[id] = &policy.Priority
+		}
+	}
+	return priorities
+}
+
+func (p *Policy) rulePriorities() map[string]*int {
+	priorities := map[string]*int{}
+	for id, rule := range p.Rules {
+		priorities[id] = &rule.Priority
+	}
+	return priorities
+}
+
+func (f *Okta) CreatePolicy(ctx context.Context, policy api.OktaPolicy) (*api.OktaPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreatePolicy"); err != nil {
+		return nil, err
+	}
+
+	policy.ID = f.newID("00p")
+	if policy.Status == "" {
+		policy.Status = "ACTIVE"
+	}
+	f.Policies[policy.ID] = &Policy{
+		OktaPolicy: policy,
+		Rules:      map[string]*api.OktaPolicyRule{},
+	}
+	reorder(f.policyPriorities(policy.Type), policy.ID, policy.Priority)
+
+	result := f.Policies[policy.ID].OktaPolicy
+	return &result, nil
+}
+
+func (f *Okta) GetPolicy(ctx context.Context, policyID string) (*api.OktaPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetPolicy"); err != nil {
+		return nil, err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok {
+		return nil, nil
+	}
+
+	result := policy.OktaPolicy
+	return &result, nil
+}
+
+// UpdatePolicy keeps the policy's type and status, which Okta doesn't
+// change on update.
+func (f *Okta) UpdatePolicy(ctx context.Context, update api.OktaPolicy) (*api.OktaPolicy, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdatePolicy"); err != nil {
+		return nil, err
+	}
+
+	policy, ok := f.Policies[update.ID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	update.Type = policy.Type
+	update.Status = policy.Status
+	policy.OktaPolicy = update
+	reorder(f.policyPriorities(policy.Type), policy.ID, update.Priority)
+
+	result := policy.OktaPolicy
+	return &result, nil
+}
+
+// DeletePolicy fails for the default policy of a type, the same as Okta
+// does.
+func (f *Okta) DeletePolicy(ctx context.Context, policyID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeletePolicy"); err != nil {
+		return err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok {
+		return nil
+	}
+
+	if policy.System {
+		return fmt.Errorf("Response not successful: Received status code 403. Response: {\"errorCode\":\"E0000006\",\"errorSummary\":\"You do not have permission to perform the requested action\"}")
+	}
+
+	delete(f.Policies, policyID)
+	return nil
+}
+
+func (f *Okta) SetPolicyActive(ctx context.Context, policyID string, active bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetPolicyActive"); err != nil {
+		return err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok {
+		return errNotFound
+	}
+
+	policy.Status = lifecycleStatus(active)
+	return nil
+}
+
+func (f *Okta) CreatePolicyRule(ctx context.Context, policyID string, rule api.OktaPolicyRule) (*api.OktaPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("CreatePolicyRule"); err != nil {
+		return nil, err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok {
+		return nil, errNotFound
+	}
+
+	rule.ID = f.newID("0pr")
+	if rule.Status == "" {
+		rule.Status = "ACTIVE"
+	}
+	policy.Rules[rule.ID] = &rule
+	reorder(policy.rulePriorities(), rule.ID, rule.Priority)
+
+	result := *policy.Rules[rule.ID]
+	return &result, nil
+}
+
+func (f *Okta) GetPolicyRule(ctx context.Context, policyID string, ruleID string) (*api.OktaPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("GetPolicyRule"); err != nil {
+		return nil, err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok || policy.Rules[ruleID] == nil {
+		return nil, nil
+	}
+
+	result := *policy.Rules[ruleID]
+	return &result, nil
+}
+
+func (f *Okta) UpdatePolicyRule(ctx context.Context, policyID string, rule api.OktaPolicyRule) (*api.OktaPolicyRule, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("UpdatePolicyRule"); err != nil {
+		return nil, err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok || policy.Rules[rule.ID] == nil {
+		return nil, errNotFound
+	}
+
+	rule.Status = policy.Rules[rule.ID].Status
+	policy.Rules[rule.ID] = &rule
+	reorder(policy.rulePriorities(), rule.ID, rule.Priority)
+
+	result := *policy.Rules[rule.ID]
+	return &result, nil
+}
+
+func (f *Okta) DeletePolicyRule(ctx context.Context, policyID string, ruleID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("DeletePolicyRule"); err != nil {
+		return err
+	}
+
+	if policy, ok := f.Policies[policyID]; ok {
+		delete(policy.Rules, ruleID)
+	}
+	return nil
+}
+
+func (f *Okta) SetPolicyRuleActive(ctx context.Context, policyID string, ruleID string, active bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.fail("SetPolicyRuleActive"); err != nil {
+		return err
+	}
+
+	policy, ok := f.Policies[policyID]
+	if !ok || policy.Rules[ruleID] == nil {
+		return errNotFound
+	}
+
+	policy.Rules[ruleID].Status = lifecycleStatus(active)
+	return nil
+}
@@ -0,0 +1,51 @@
+package fake
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var (
+	certificateOnce sync.Once
+	certificate     string
+)
+
+// samlMetadata returns IdP metadata for the app laid out the way Okta does,
+// with a self signed certificate shared by every app.
+func samlMetadata(appID string) string {
+	certificateOnce.Do(func() {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			panic(err)
+		}
+
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "fake"},
+			NotBefore:    time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			panic(err)
+		}
+
+		certificate = base64.StdEncoding.EncodeToString(der)
+	})
+
+	return fmt.Sprintf(`<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="http://www.okta.com/%[1]s">`+
+		`<md:IDPSSODescriptor protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">`+
+		`<md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>%[2]s</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor>`+
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://example.okta.com/app/%[1]s/sso/saml"/>`+
+		`<md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://example.okta.com/app/%[1]s/sso/saml"/>`+
+		`</md:IDPSSODescriptor></md:EntityDescriptor>`, appID, certificate)
+}
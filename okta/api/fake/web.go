@@ -0,0 +1,72 @@
+package fake
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+// Web is an in-memory implementation of api.AdminWebAPI. Provisioning an app
+// toggles the PUSH_NEW_USERS feature on it in the fake Okta org, as Okta does.
+type Web struct {
+	Okta        *Okta
+	Credentials map[string][2]string
+	Errors      map[string]error
+}
+
+var _ api.AdminWebAPI = &Web{}
+
+func NewWeb(okta *Okta) *Web {
+	return &Web{
+		Okta:        okta,
+		Credentials: map[string][2]string{},
+		Errors:      map[string]error{},
+	}
+}
+
+func (f *Web) Validate() error {
+	return f.Errors["Validate"]
+}
+
+func (f *Web) SetAWSProvisioning(ctx context.Context, appID string, accessKey string, secretKey string) error {
+	if err := f.Errors["SetAWSProvisioning"]; err != nil {
+		return err
+	}
+
+	f.Credentials[appID] = [2]string{accessKey, secretKey}
+	return f.setFeature(appID, true)
+}
+
+func (f *Web) RevokeAWSProvisioning(ctx context.Context, appID string) error {
+	if err := f.Errors["RevokeAWSProvisioning"]; err != nil {
+		return err
+	}
+
+	delete(f.Credentials, appID)
+	return f.setFeature(appID, false)
+}
+
+func (f *Web) setFeature(appID string, enabled bool) error {
+	f.Okta.mu.Lock()
+	defer f.Okta.mu.Unlock()
+
+	app, ok := f.Okta.Applications[appID]
+	if !ok {
+		return fmt.Errorf("Provisioning did not yield successful 404")
+	}
+
+	features := []string{}
+	for _, feature := range app.Features {
+		if feature != "PUSH_NEW_USERS" {
+			features = append(features, feature)
+		}
+	}
+
+	if enabled {
+		features = append(features, "PUSH_NEW_USERS")
+	}
+
+	app.Features = features
+	return nil
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+)
+
+// OktaAPI covers the operations of the Okta API client. Resources depend on
+// it rather than on Okta, so they can be tested against the in-memory fake.
+type OktaAPI interface {
+	GetApplication(ctx context.Context, appID string) (*OktaApplication, error)
+	FindApplicationByLabel(ctx context.Context, label string) (*OktaApplication, error)
+	CreateAwsApplication(ctx context.Context, name string, providerArn string) (*OktaApplication, error)
+	CreateApplication(ctx context.Context, application OktaApplicationContents) (*OktaApplication, error)
+	UpdateAwsApplication(ctx context.Context, appId string, name string, providerArn string) (*OktaApplication, error)
+	UpdateApplication(ctx context.Context, application OktaApplicationContents) (*OktaApplication, error)
+	DeactivateApplication(ctx context.Context, appID string) error
+	DeleteApplication(ctx context.Context, appID string) error
+	GetSAMLMetadata(ctx context.Context, appID string, keyID string) (string, error)
+
+	GetProvisioningConnection(ctx context.Context, appID string) (*OktaProvisioningConnection, error)
+	SetProvisioningConnection(ctx context.Context, appID string, baseURL string, token string) (*OktaProvisioningConnection, error)
+	DeactivateProvisioningConnection(ctx context.Context, appID string) error
+	GetProvisioningFeature(ctx context.Context, appID string) (*OktaProvisioningFeature, error)
+	UpdateProvisioningFeature(ctx context.Context, appID string, capabilities OktaProvisioningCapabilities) (*OktaProvisioningFeature, error)
+
+	CreateAuthServer(ctx context.Context, server OktaAuthServer) (*OktaAuthServer, error)
+	GetAuthServer(ctx context.Context, serverID string) (*OktaAuthServer, error)
+	UpdateAuthServer(ctx context.Context, server OktaAuthServer) (*OktaAuthServer, error)
+	DeleteAuthServer(ctx context.Context, serverID string) error
+	SetAuthServerActive(ctx context.Context, serverID string, active bool) error
+	CreateAuthServerScope(ctx context.Context, serverID string, scope OktaAuthServerScope) (*OktaAuthServerScope, error)
+	GetAuthServerScope(ctx context.Context, serverID string, scopeID string) (*OktaAuthServerScope, error)
+	UpdateAuthServerScope(ctx context.Context, serverID string, scope OktaAuthServerScope) (*OktaAuthServerScope, error)
+	DeleteAuthServerScope(ctx context.Context, serverID string, scopeID string) error
+	CreateAuthServerClaim(ctx context.Context, serverID string, claim OktaAuthServerClaim) (*OktaAuthServerClaim, error)
+	GetAuthServerClaim(ctx context.Context, serverID string, claimID string) (*OktaAuthServerClaim, error)
+	UpdateAuthServerClaim(ctx context.Context, serverID string, claim OktaAuthServerClaim) (*OktaAuthServerClaim, error)
+	DeleteAuthServerClaim(ctx context.Context, serverID string, claimID string) error
+	CreateAuthServerPolicy(ctx context.Context, serverID string, policy OktaAuthServerPolicy) (*OktaAuthServerPolicy, error)
+	GetAuthServerPolicy(ctx context.Context, serverID string, policyID string) (*OktaAuthServerPolicy, error)
+	UpdateAuthServerPolicy(ctx context.Context, serverID string, policy OktaAuthServerPolicy) (*OktaAuthServerPolicy, error)
+	DeleteAuthServerPolicy(ctx context.Context, serverID string, policyID string) error
+	SetAuthServerPolicyActive(ctx context.Context, serverID string, policyID string, active bool) error
+	CreateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule OktaAuthServerPolicyRule) (*OktaAuthServerPolicyRule, error)
+	GetAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) (*OktaAuthServerPolicyRule, error)
+	UpdateAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, rule OktaAuthServerPolicyRule) (*OktaAuthServerPolicyRule, error)
+	DeleteAuthServerPolicyRule(ctx context.Context, serverID string, policyID string, ruleID string) error
+	SetAuthServerPolicyRuleActive(ctx context.Context, serverID string, policyID string, ruleID string, active bool) error
+
+	CreatePolicy(ctx context.Context, policy OktaPolicy) (*OktaPolicy, error)
+	GetPolicy(ctx context.Context, policyID string) (*OktaPolicy, error)
+	UpdatePolicy(ctx context.Context, policy OktaPolicy) (*OktaPolicy, error)
+	DeletePolicy(ctx context.Context, policyID string) error
+	SetPolicyActive(ctx context.Context, policyID string, active bool) error
+	CreatePolicyRule(ctx context.Context, policyID string, rule OktaPolicyRule) (*OktaPolicyRule, error)
+	GetPolicyRule(ctx context.Context, policyID string, ruleID string) (*OktaPolicyRule, error)
+	UpdatePolicyRule(ctx context.Context, policyID string, rule OktaPolicyRule) (*OktaPolicyRule, error)
+	DeletePolicyRule(ctx context.Context, policyID string, ruleID string) error
+	SetPolicyRuleActive(ctx context.Context, policyID string, ruleID string, active bool) error
+
+	GetUserIDByEmail(ctx context.Context, user string, domain string) (string, error)
+	AddAppMember(ctx context.Context, appId string, userId string, role string, roles []string) (*OktaUser, error)
+	AssignAppMember(ctx context.Context, appId string, assignment OktaAppUserAssignment) (*OktaUser, error)
+	GetAppMember(ctx context.Context, appId string, userId string) (*OktaUser, error)
+	ListAppMembers(ctx context.Context, appId string) ([]OktaUser, error)
+	RemoveAppMember(ctx context.Context, appId string, userId string, sendEmail bool) error
+	SetAppMemberScope(ctx context.Context, appId string, userId string, scope string) (*OktaUser, error)
+	ListAppMemberGroups(ctx context.Context, appId string, userId string) ([]OktaGroup, error)
+	GetAppRoles(ctx context.Context, appId string) ([]string, error)
+	GetAppUserSchema(ctx context.Context, appId string) (*OktaAppUserSchema, error)
+}
+
+// AdminWebAPI covers the operations driven through the Okta Admin WebUI, for
+// settings the API doesn't expose.
+type AdminWebAPI interface {
+	Validate() error
+	SetAWSProvisioning(ctx context.Context, appID string, accessKey string, secretKey string) error
+	RevokeAWSProvisioning(ctx context.Context, appID string) error
+}
+
+var _ OktaAPI = &Okta{}
+var _ AdminWebAPI = &OktaWebClient{}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"sync"
+)
+
+// requestLimiter bounds how many requests are in flight to Okta at once,
+// both overall and for each rate limit bucket, so that parallel resource
+// operations queue up rather than use up the org's rate limits together.
+type requestLimiter struct {
+	total     chan struct{}
+	perBucket int
+
+	mu      sync.Mutex
+	buckets map[string]chan struct{}
+}
+
+// newRequestLimiter returns a limiter allowing max requests at once, and
+// perBucket requests at once to a single bucket. Zero means no limit.
+func newRequestLimiter(max int, perBucket int) *requestLimiter {
+	limiter := &requestLimiter{
+		perBucket: perBucket,
+		buckets:   map[string]chan struct{}{},
+	}
+
+	if max > 0 {
+		limiter.total = make(chan struct{}, max)
+	}
+
+	return limiter
+}
+
+func (l *requestLimiter) bucket(name string) chan struct{} {
+	if l.perBucket <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[name]
+	if !ok {
+		bucket = make(chan struct{}, l.perBucket)
+		l.buckets[name] = bucket
+	}
+
+	return bucket
+}
+
+// acquire waits for a free slot in the bucket and overall, returning the
+// function to release them once the request completes.
+func (l *requestLimiter) acquire(ctx context.Context, name string) (func(), error) {
+	bucket := l.bucket(name)
+
+	if err := acquireSlot(ctx, bucket); err != nil {
+		return nil, err
+	}
+
+	if err := acquireSlot(ctx, l.total); err != nil {
+		releaseSlot(bucket)
+		return nil, err
+	}
+
+	return func() {
+		releaseSlot(l.total)
+		releaseSlot(bucket)
+	}, nil
+}
+
+func acquireSlot(ctx context.Context, slots chan struct{}) error {
+	if slots == nil {
+		return nil
+	}
+
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func releaseSlot(slots chan struct{}) {
+	if slots != nil {
+		<-slots
+	}
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRequestLimiter(t *testing.T) {
+	limiter := newRequestLimiter(2, 1)
+
+	release, err := limiter.acquire(context.Background(), "GET /api/v1/apps")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := limiter.acquire(ctx, "GET /api/v1/apps"); err != context.DeadlineExceeded {
+		t.Fatalf("expected the bucket to be limited to one request, got %v", err)
+	}
+
+	releaseUsers, err := limiter.acquire(context.Background(), "GET /api/v1/users")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := limiter.acquire(ctx, "GET /api/v1/groups"); err != context.DeadlineExceeded {
+		t.Fatalf("expected requests to be limited to two overall, got %v", err)
+	}
+
+	release()
+	releaseUsers()
+
+	if _, err := limiter.acquire(context.Background(), "GET /api/v1/apps"); err != nil {
+		t.Fatalf("expected a slot once released, got %s", err)
+	}
+}
@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+const OAuthTokenPath = "/oauth2/v1/token"
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+// Tokens are refreshed this long before they expire, so a request never
+// goes out with a token that lapses in flight.
+const tokenExpiryLeeway = time.Minute
+
+// The scopes needed by the APIs whose scopes aren't requested by default,
+// by the path the API is under, so a request refused for lack of one can say
+// which.
+var scopesByPath = []struct {
+	Prefix string
+	Read   string
+	Manage string
+}{
+	{"/api/v1/authorizationServers", "okta.authorizationServers.read", "okta.authorizationServers.manage"},
+	{"/api/v1/policies", "okta.policies.read", "okta.policies.manage"},
+}
+
+// requiredScope returns the scope a request needs, or an empty string when
+// it is one of the scopes requested by default.
+func requiredScope(method string, rawURL string) string {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+
+	for _, scopes := range scopesByPath {
+		if path != scopes.Prefix && !strings.HasPrefix(path, scopes.Prefix+"/") {
+			continue
+		}
+
+		if method == http.MethodGet {
+			return scopes.Read
+		}
+		return scopes.Manage
+	}
+
+	return ""
+}
+
+// OAuthCredentials authenticate the API client as an Okta service app,
+// using a client assertion signed with the app's private key. The access
+// token is cached and shared by every client holding the credentials.
+type OAuthCredentials struct {
+	ClientID   string
+	PrivateKey string
+	KeyID      string
+	Scopes     []string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type oauthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+type oauthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// AccessToken returns the cached access token, requesting a new one from the
+// org authorization server when there is none or it is about to expire.
+func (c *OAuthCredentials) AccessToken(ctx context.Context, hostURL string) (string, error) {
+	return c.accessToken(hostURL, func(req *resty.Request) (*resty.Response, error) {
+		return req.SetContext(ctx).Post(OAuthTokenPath)
+	})
+}
+
+// accessToken is AccessToken with the request for a new token sent by send,
+// so the API client can retry and limit it like its other requests.
+func (c *OAuthCredentials) accessToken(hostURL string, send func(*resty.Request) (*resty.Response, error)) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && time.Now().Add(tokenExpiryLeeway).Before(c.expiry) {
+		return c.token, nil
+	}
+
+	resp, err := send(c.tokenRequest(hostURL))
+	if err != nil {
+		return "", err
+	}
+
+	token := resp.Result().(*oauthTokenResponse)
+	c.token = token.AccessToken
+	c.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+
+	return c.token, nil
+}
+
+// tokenRequest returns the request for a new access token. Each attempt to
+// send it signs a new client assertion, as Okta only accepts one once, and
+// an error response is returned as an error, so it can be retried.
+func (c *OAuthCredentials) tokenRequest(hostURL string) *resty.Request {
+	client := resty.New().SetHostURL(hostURL)
+
+	client.OnBeforeRequest(func(_ *resty.Client, r *resty.Request) error {
+		assertion, err := c.clientAssertion(strings.TrimRight(hostURL, "/") + OAuthTokenPath)
+		if err != nil {
+			return err
+		}
+
+		r.SetFormData(map[string]string{
+			"grant_type":            "client_credentials",
+			"scope":                 strings.Join(c.Scopes, " "),
+			"client_assertion_type": clientAssertionType,
+			"client_assertion":      assertion,
+		})
+		return nil
+	})
+
+	client.OnAfterResponse(func(_ *resty.Client, r *resty.Response) error {
+		if !r.IsError() {
+			return nil
+		}
+
+		oauthErr := r.Error().(*oauthErrorResponse)
+		return fmt.Errorf("Could not get an access token for client %s: received status code %d, %s: %s", c.ClientID, r.StatusCode(), oauthErr.Error, oauthErr.ErrorDescription)
+	})
+
+	return client.R().
+		SetHeader("Accept", "application/json").
+		SetResult(&oauthTokenResponse{}).
+		SetError(&oauthErrorResponse{})
+}
+
+// Invalidate drops the cached access token, so the next request gets a new
+// one. Used when Okta rejects the token before it was due to expire.
+func (c *OAuthCredentials) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.token = ""
+	c.expiry = time.Time{}
+}
+
+// clientAssertion builds the signed JWT used to authenticate the client with
+// the token endpoint.
+func (c *OAuthCredentials) clientAssertion(audience string) (string, error) {
+	key, keyID, err := parsePrivateKey(c.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+
+	if c.KeyID != "" {
+		keyID = c.KeyID
+	}
+
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	header := map[string]interface{}{
+		"alg": "RS256",
+		"typ": "JWT",
+	}
+	if keyID != "" {
+		header["kid"] = keyID
+	}
+
+	claims := map[string]interface{}{
+		"aud": audience,
+		"iss": c.ClientID,
+		"sub": c.ClientID,
+		"iat": now.Unix(),
+		"exp": now.Add(5 * time.Minute).Unix(),
+		"jti": hex.EncodeToString(jti),
+	}
+
+	encodedHeader, err := encodeJWTSegment(header)
+	if err != nil {
+		return "", err
+	}
+
+	encodedClaims, err := encodeJWTSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := encodedHeader + "." + encodedClaims
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func encodeJWTSegment(value interface{}) (string, error) {
+	segment, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(segment), nil
+}
+
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	D       string `json:"d"`
+	P       string `json:"p"`
+	Q       string `json:"q"`
+}
+
+// parsePrivateKey reads an RSA private key given either as PEM, in PKCS #1
+// or PKCS #8 form, or as a JWK. The key ID is returned when the JWK has one.
+func parsePrivateKey(value string) (*rsa.PrivateKey, string, error) {
+	value = strings.TrimSpace(value)
+
+	if strings.HasPrefix(value, "{") {
+		return parseJSONWebKey([]byte(value))
+	}
+
+	block, _ := pem.Decode([]byte(value))
+	if block == nil {
+		return nil, "", fmt.Errorf("The private key must be a PEM encoded key or a JWK")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, "", nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("Could not parse the private key: %s", err)
+	}
+
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, "", fmt.Errorf("The private key must be an RSA key")
+	}
+
+	return key, "", nil
+}
+
+func parseJSONWebKey(value []byte) (*rsa.PrivateKey, string, error) {
+	jwk := jsonWebKey{}
+	if err := json.Unmarshal(value, &jwk); err != nil {
+		return nil, "", fmt.Errorf("Could not parse the private key JWK: %s", err)
+	}
+
+	if jwk.KeyType != "RSA" {
+		return nil, "", fmt.Errorf("The private key JWK must be an RSA key, got %q", jwk.KeyType)
+	}
+
+	values := map[string]*big.Int{}
+	for name, encoded := range map[string]string{"n": jwk.N, "e": jwk.E, "d": jwk.D, "p": jwk.P, "q": jwk.Q} {
+		if encoded == "" {
+			return nil, "", fmt.Errorf("The private key JWK is missing %q", name)
+		}
+
+		decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "="))
+		if err != nil {
+			return nil, "", fmt.Errorf("The private key JWK has an invalid %q: %s", name, err)
+		}
+		values[name] = new(big.Int).SetBytes(decoded)
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: rsa.PublicKey{
+			N: values["n"],
+			E: int(values["e"].Int64()),
+		},
+		D:      values["d"],
+		Primes: []*big.Int{values["p"], values["q"]},
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, "", fmt.Errorf("The private key JWK is invalid: %s", err)
+	}
+	key.Precompute()
+
+	return key, jwk.KeyID, nil
+}
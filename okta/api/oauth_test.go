@@ -0,0 +1,314 @@
+package api
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOAuthCredentials_clientAssertion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	pemKey := string(pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(key),
+	}))
+
+	jwk := fmt.Sprintf(`{"kty":"RSA","kid":"jwk-kid","n":"%s","e":"%s","d":"%s","p":"%s","q":"%s"}`,
+		encode(key.N), encode(big.NewInt(int64(key.E))), encode(key.D), encode(key.Primes[0]), encode(key.Primes[1]))
+
+	cases := map[string]struct {
+		PrivateKey string
+		KeyID      string
+		ExpectKid  string
+	}{
+		"pem":          {PrivateKey: pemKey, KeyID: "pem-kid", ExpectKid: "pem-kid"},
+		"jwk":          {PrivateKey: jwk, ExpectKid: "jwk-kid"},
+		"jwk override": {PrivateKey: jwk, KeyID: "other-kid", ExpectKid: "other-kid"},
+	}
+
+	for name, tc := range cases {
+		credentials := &OAuthCredentials{ClientID: "client", PrivateKey: tc.PrivateKey, KeyID: tc.KeyID}
+		assertion, err := credentials.clientAssertion("https://example.okta.com/oauth2/v1/token")
+		if err != nil {
+			t.Fatalf("%s: err: %s", name, err)
+		}
+
+		parts := strings.Split(assertion, ".")
+		if len(parts) != 3 {
+			t.Fatalf("%s: expected three segments, got %d", name, len(parts))
+		}
+
+		header := map[string]interface{}{}
+		claims := map[string]interface{}{}
+		decodeJWTSegment(t, parts[0], &header)
+		decodeJWTSegment(t, parts[1], &claims)
+
+		if header["kid"] != tc.ExpectKid {
+			t.Fatalf("%s: expected kid %q, got %q", name, tc.ExpectKid, header["kid"])
+		}
+
+		if claims["iss"] != "client" || claims["sub"] != "client" {
+			t.Fatalf("%s: unexpected claims %v", name, claims)
+		}
+
+		signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Fatalf("%s: signature did not verify: %s", name, err)
+		}
+	}
+}
+
+func TestOAuthCredentials_AccessTokenIsCached(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.Form.Get("client_assertion_type") != clientAssertionType || r.Form.Get("scope") != "okta.apps.read" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, requests)
+	}))
+	defer server.Close()
+
+	credentials := &OAuthCredentials{
+		ClientID: "client",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		})),
+		Scopes: []string{"okta.apps.read"},
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := credentials.AccessToken(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if token != "token-1" {
+			t.Fatalf("expected the cached token, got %q", token)
+		}
+	}
+
+	credentials.Invalidate()
+	token, err := credentials.AccessToken(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if token != "token-2" {
+		t.Fatalf("expected a new token after invalidating, got %q", token)
+	}
+}
+
+func TestOkta_namesMissingScope(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == OAuthTokenPath {
+			fmt.Fprint(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errorCode":"E0000006","errorSummary":"You do not have permission to perform the requested action"}`)
+	}))
+	defer server.Close()
+
+	client := &Okta{
+		HostURL: server.URL,
+		OAuth: &OAuthCredentials{
+			ClientID: "client",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			})),
+			Scopes: []string{"okta.apps.read"},
+		},
+	}
+
+	_, err = client.GetAuthServer(context.Background(), "aus1example")
+	if err == nil || !strings.Contains(err.Error(), "needs the okta.authorizationServers.read scope") {
+		t.Fatalf("expected an error naming the missing scope, got %v", err)
+	}
+
+	_, err = client.CreateAuthServer(context.Background(), OktaAuthServer{Name: "internal-apis"})
+	if err == nil || !strings.Contains(err.Error(), "needs the okta.authorizationServers.manage scope") {
+		t.Fatalf("expected an error naming the missing scope, got %v", err)
+	}
+
+	_, err = client.GetPolicyRule(context.Background(), "00p1example", "0pr1example")
+	if err == nil || !strings.Contains(err.Error(), "needs the okta.policies.read scope") {
+		t.Fatalf("expected an error naming the missing scope, got %v", err)
+	}
+
+	err = client.DeletePolicy(context.Background(), "00p1example")
+	if err == nil || !strings.Contains(err.Error(), "needs the okta.policies.manage scope") {
+		t.Fatalf("expected an error naming the missing scope, got %v", err)
+	}
+}
+
+func TestOkta_oauthKeepsRequestHeaders(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var accept, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == OAuthTokenPath {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"token","token_type":"Bearer","expires_in":3600}`)
+			return
+		}
+
+		accept = r.Header.Get("Accept")
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata"/>`)
+	}))
+	defer server.Close()
+
+	client := &Okta{
+		HostURL: server.URL,
+		OAuth: &OAuthCredentials{
+			ClientID: "client",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			})),
+			Scopes: []string{"okta.apps.read"},
+		},
+	}
+
+	if _, err := client.GetSAMLMetadata(context.Background(), "0oa1example", "kid1example"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if accept != "application/xml" {
+		t.Fatalf("expected the metadata to be requested as XML, got Accept %q", accept)
+	}
+
+	if authorization != "Bearer token" {
+		t.Fatalf("expected the access token to be sent, got %q", authorization)
+	}
+}
+
+func TestOkta_oauthRetriesTokenRequests(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	assertions := map[string]bool{}
+	tokens, rejected := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == OAuthTokenPath {
+			r.ParseForm()
+			assertions[r.Form.Get("client_assertion")] = true
+
+			// The first attempt is rate limited, and the token it
+			// eventually returns is rejected as if it was revoked.
+			tokens++
+			if tokens == 1 {
+				w.WriteHeader(http.StatusTooManyRequests)
+				fmt.Fprint(w, `{"error":"rate_limited","error_description":"Too many requests"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":3600}`, tokens)
+			return
+		}
+
+		if r.Header.Get("Authorization") == "Bearer token-2" {
+			rejected++
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errorCode":"E0000011","errorSummary":"Invalid token provided"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"id":"0oa1example","label":"ACME-AwsAccount"}`)
+	}))
+	defer server.Close()
+
+	client := &Okta{
+		HostURL: server.URL,
+		OAuth: &OAuthCredentials{
+			ClientID: "client",
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{
+				Type:  "RSA PRIVATE KEY",
+				Bytes: x509.MarshalPKCS1PrivateKey(key),
+			})),
+			Scopes: []string{"okta.apps.read"},
+		},
+		RetryPolicy: RetryPolicy{
+			MaxRetries:  2,
+			MinWait:     time.Millisecond,
+			MaxWait:     time.Millisecond,
+			StatusCodes: []int{http.StatusTooManyRequests},
+		},
+	}
+
+	app, err := client.GetApplication(context.Background(), "0oa1example")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if app == nil || app.Label != "ACME-AwsAccount" {
+		t.Fatalf("expected the app to be read with a new token, got %+v", app)
+	}
+
+	if tokens != 3 || rejected != 1 {
+		t.Fatalf("expected three token requests and one rejected token, got %d and %d", tokens, rejected)
+	}
+
+	if len(assertions) != 3 {
+		t.Fatalf("expected each token request to sign a new client assertion, got %d", len(assertions))
+	}
+}
+
+func decodeJWTSegment(t *testing.T, segment string, v interface{}) {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := json.Unmarshal(decoded, v); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
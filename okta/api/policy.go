@@ -0,0 +1,304 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// OktaPolicy is an org-wide policy: sign-on (OKTA_SIGN_ON), password
+// (PASSWORD), factor enrollment (MFA_ENROLL) or app sign-on (ACCESS_POLICY).
+// Policies of a type are evaluated in order of priority, starting from 1,
+// and the first that applies to the user decides which of its rules run.
+type OktaPolicy struct {
+	ID          string                `json:"id,omitempty"`
+	Type        string                `json:"type"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	Status      string                `json:"status,omitempty"`
+	Priority    int                   `json:"priority,omitempty"`
+	Conditions  *OktaPolicyConditions `json:"conditions,omitempty"`
+	Settings    *OktaPolicySettings   `json:"settings,omitempty"`
+	System      bool                  `json:"system,omitempty"`
+}
+
+type OktaPolicyConditions struct {
+	People       *OktaPolicyPeopleCondition `json:"people,omitempty"`
+	AuthProvider *OktaAuthProviderCondition `json:"authProvider,omitempty"`
+}
+
+// OktaPolicyPeopleCondition is who a policy applies to. Policies can only
+// include groups.
+type OktaPolicyPeopleCondition struct {
+	Groups OktaInclude `json:"groups"`
+}
+
+// OktaAuthProviderCondition is where the users of a password policy are
+// mastered, OKTA or ACTIVE_DIRECTORY.
+type OktaAuthProviderCondition struct {
+	Provider string `json:"provider"`
+}
+
+// OktaPolicySettings are the settings of PASSWORD and MFA_ENROLL policies.
+// Factors are keyed by their Okta name, such as okta_otp or google_otp.
+type OktaPolicySettings struct {
+	Password *OktaPasswordSettings         `json:"password,omitempty"`
+	Factors  map[string]OktaFactorSettings `json:"factors,omitempty"`
+}
+
+type OktaPasswordSettings struct {
+	Complexity OktaPasswordComplexity `json:"complexity"`
+	Age        OktaPasswordAge        `json:"age"`
+	Lockout    OktaPasswordLockout    `json:"lockout"`
+}
+
+// OktaPasswordComplexity is what a password needs to contain. The minimums
+// of each kind of character are 0 or 1.
+type OktaPasswordComplexity struct {
+	MinLength         int                    `json:"minLength"`
+	MinLowerCase      int                    `json:"minLowerCase"`
+	MinUpperCase      int                    `json:"minUpperCase"`
+	MinNumber         int                    `json:"minNumber"`
+	MinSymbol         int                    `json:"minSymbol"`
+	ExcludeUsername   bool                   `json:"excludeUsername"`
+	ExcludeAttributes []string               `json:"excludeAttributes"`
+	Dictionary        OktaPasswordDictionary `json:"dictionary"`
+}
+
+type OktaPasswordDictionary struct {
+	Common OktaPasswordCommonDictionary `json:"common"`
+}
+
+// OktaPasswordCommonDictionary rejects passwords found in a list of
+// commonly used passwords when Exclude is set.
+type OktaPasswordCommonDictionary struct {
+	Exclude bool `json:"exclude"`
+}
+
+// OktaPasswordAge is how long passwords last. A maximum age of 0 never
+// expires them.
+type OktaPasswordAge struct {
+	MaxAgeDays     int `json:"maxAgeDays"`
+	ExpireWarnDays int `json:"expireWarnDays"`
+	MinAgeMinutes  int `json:"minAgeMinutes"`
+	HistoryCount   int `json:"historyCount"`
+}
+
+// OktaPasswordLockout is when users are locked out after failing to sign
+// in. An auto unlock of 0 leaves them locked out until an admin unlocks
+// them.
+type OktaPasswordLockout struct {
+	MaxAttempts         int  `json:"maxAttempts"`
+	AutoUnlockMinutes   int  `json:"autoUnlockMinutes"`
+	ShowLockoutFailures bool `json:"showLockoutFailures"`
+}
+
+type OktaFactorSettings struct {
+	Enroll OktaFactorEnroll `json:"enroll"`
+}
+
+// OktaFactorEnroll is whether users have to enroll in the factor: REQUIRED,
+// OPTIONAL or NOT_ALLOWED.
+type OktaFactorEnroll struct {
+	Self string `json:"self"`
+}
+
+// OktaPolicyRule is a rule of a policy, which decides what users the policy
+// applies to can do when they match its conditions. Rules are evaluated in
+// order of priority within the policy.
+type OktaPolicyRule struct {
+	ID         string                   `json:"id,omitempty"`
+	Type       string                   `json:"type"`
+	Name       string                   `json:"name"`
+	Status     string                   `json:"status,omitempty"`
+	Priority   int                      `json:"priority,omitempty"`
+	Conditions OktaPolicyRuleConditions `json:"conditions"`
+	Actions    OktaPolicyRuleActions    `json:"actions"`
+	System     bool                     `json:"system,omitempty"`
+}
+
+type OktaPolicyRuleConditions struct {
+	People      *OktaPolicyRulePeopleCondition `json:"people,omitempty"`
+	Network     *OktaNetworkCondition          `json:"network,omitempty"`
+	AuthContext *OktaAuthContextCondition      `json:"authContext,omitempty"`
+}
+
+// OktaPolicyRulePeopleCondition is who a rule applies to. Only app sign-on
+// rules can include people, the other rules can only exclude users.
+type OktaPolicyRulePeopleCondition struct {
+	Users  *OktaIncludeExclude `json:"users,omitempty"`
+	Groups *OktaIncludeExclude `json:"groups,omitempty"`
+}
+
+// OktaNetworkCondition is where users sign in from. The zones are only
+// given for a ZONE connection, and either included or excluded.
+type OktaNetworkCondition struct {
+	Connection string   `json:"connection"`
+	Include    []string `json:"include,omitempty"`
+	Exclude    []string `json:"exclude,omitempty"`
+}
+
+// OktaAuthContextCondition is how users of a sign-on rule authenticate,
+// ANY or RADIUS.
+type OktaAuthContextCondition struct {
+	AuthType string `json:"authType"`
+}
+
+// OktaPolicyRuleActions holds the action of each type of rule, only the
+// one for the rule's type is set.
+type OktaPolicyRuleActions struct {
+	SignOn                   *OktaSignOnAction    `json:"signon,omitempty"`
+	PasswordChange           *OktaAccessAction    `json:"passwordChange,omitempty"`
+	SelfServicePasswordReset *OktaAccessAction    `json:"selfServicePasswordReset,omitempty"`
+	SelfServiceUnlock        *OktaAccessAction    `json:"selfServiceUnlock,omitempty"`
+	Enroll                   *OktaEnrollAction    `json:"enroll,omitempty"`
+	AppSignOn                *OktaAppSignOnAction `json:"appSignOn,omitempty"`
+}
+
+// OktaSignOnAction is whether users are let in, which factors they are
+// asked for and how long their session lasts. The factor prompt mode is
+// ALWAYS, DEVICE or SESSION, and the factor lifetime is how long a device or
+// session is remembered for in minutes.
+type OktaSignOnAction struct {
+	Access                  string            `json:"access"`
+	RequireFactor           bool              `json:"requireFactor"`
+	FactorPromptMode        string            `json:"factorPromptMode,omitempty"`
+	RememberDeviceByDefault bool              `json:"rememberDeviceByDefault"`
+	FactorLifetime          int               `json:"factorLifetime,omitempty"`
+	Session                 OktaSignOnSession `json:"session"`
+}
+
+type OktaSignOnSession struct {
+	UsePersistentCookie       bool `json:"usePersistentCookie"`
+	MaxSessionIdleMinutes     int  `json:"maxSessionIdleMinutes"`
+	MaxSessionLifetimeMinutes int  `json:"maxSessionLifetimeMinutes"`
+}
+
+// OktaAccessAction is whether users can do something, ALLOW or DENY.
+type OktaAccessAction struct {
+	Access string `json:"access"`
+}
+
+// OktaEnrollAction is when users are asked to enroll in factors: CHALLENGE,
+// LOGIN or NEVER.
+type OktaEnrollAction struct {
+	Self string `json:"self"`
+}
+
+type OktaAppSignOnAction struct {
+	Access             string                 `json:"access"`
+	VerificationMethod OktaVerificationMethod `json:"verificationMethod"`
+}
+
+// OktaVerificationMethod is what users have to verify to sign in to an app.
+// The factor mode is 1FA or 2FA, and users are asked again once the ISO 8601
+// duration to reauthenticate in has passed.
+type OktaVerificationMethod struct {
+	Type             string `json:"type"`
+	FactorMode       string `json:"factorMode"`
+	ReauthenticateIn string `json:"reauthenticateIn"`
+}
+
+func policyURL(policyID string, path ...interface{}) string {
+	url := fmt.Sprintf("/api/v1/policies/%s", policyID)
+	for _, part := range path {
+		url += fmt.Sprintf("/%s", part)
+	}
+	return url
+}
+
+func (o *Okta) CreatePolicy(ctx context.Context, policy OktaPolicy) (*OktaPolicy, error) {
+	result := &OktaPolicy{}
+	if err := o.sendResource(ctx, resty.MethodPost, "/api/v1/policies", policy, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetPolicy returns the policy, or nil when it doesn't exist.
+func (o *Okta) GetPolicy(ctx context.Context, policyID string) (*OktaPolicy, error) {
+	result := &OktaPolicy{}
+	found, err := o.getResource(ctx, policyURL(policyID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+// UpdatePolicy merges the policy into the policy as it currently is in
+// Okta, so settings it doesn't model, such as password recovery, keep their
+// values rather than being cleared.
+func (o *Okta) UpdatePolicy(ctx context.Context, policy OktaPolicy) (*OktaPolicy, error) {
+	current := map[string]interface{}{}
+	found, err := o.getResource(ctx, policyURL(policy.ID), &current)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		return nil, fmt.Errorf("Could not update policy %s, it was not found", policy.ID)
+	}
+
+	updateJSON, err := json.Marshal(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var update map[string]interface{}
+	if err := json.Unmarshal(updateJSON, &update); err != nil {
+		return nil, err
+	}
+
+	result := &OktaPolicy{}
+	if err := o.sendResource(ctx, resty.MethodPut, policyURL(policy.ID), mergeJSONObjects(current, update), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeletePolicy deletes the policy and its rules. Okta doesn't allow the
+// default policy of a type to be deleted.
+func (o *Okta) DeletePolicy(ctx context.Context, policyID string) error {
+	return o.deleteResource(ctx, policyURL(policyID))
+}
+
+// SetPolicyActive activates or deactivates the policy. An inactive policy
+// is skipped when Okta looks for the policy that applies to a user.
+func (o *Okta) SetPolicyActive(ctx context.Context, policyID string, active bool) error {
+	return o.setLifecycle(ctx, policyURL(policyID), active)
+}
+
+func (o *Okta) CreatePolicyRule(ctx context.Context, policyID string, rule OktaPolicyRule) (*OktaPolicyRule, error) {
+	result := &OktaPolicyRule{}
+	if err := o.sendResource(ctx, resty.MethodPost, policyURL(policyID, "rules"), rule, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) GetPolicyRule(ctx context.Context, policyID string, ruleID string) (*OktaPolicyRule, error) {
+	result := &OktaPolicyRule{}
+	found, err := o.getResource(ctx, policyURL(policyID, "rules", ruleID), result)
+	if err != nil || !found {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) UpdatePolicyRule(ctx context.Context, policyID string, rule OktaPolicyRule) (*OktaPolicyRule, error) {
+	result := &OktaPolicyRule{}
+	if err := o.sendResource(ctx, resty.MethodPut, policyURL(policyID, "rules", rule.ID), rule, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (o *Okta) DeletePolicyRule(ctx context.Context, policyID string, ruleID string) error {
+	return o.deleteResource(ctx, policyURL(policyID, "rules", ruleID))
+}
+
+func (o *Okta) SetPolicyRuleActive(ctx context.Context, policyID string, ruleID string, active bool) error {
+	return o.setLifecycle(ctx, policyURL(policyID, "rules", ruleID), active)
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOkta_updatePolicyKeepsUnmanagedSettings(t *testing.T) {
+	current := `{"id":"00p1password","type":"PASSWORD","name":"Staff","description":"","status":"ACTIVE","priority":1,"system":false,` +
+		`"conditions":{"people":{"groups":{"include":["00g1staff","00g1contractors"]}},"authProvider":{"provider":"OKTA"}},` +
+		`"settings":{"password":{"complexity":{"minLength":8,"excludeAttributes":["firstName"]},"age":{"maxAgeDays":0},"lockout":{"maxAttempts":10}},` +
+		`"recovery":{"factors":{"okta_email":{"status":"ACTIVE"}}},"delegation":{"options":{"skipUnlock":false}}}}`
+
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/policies/00p1password" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet {
+			fmt.Fprint(w, current)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &sent); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	client := &Okta{HostURL: server.URL, APIKey: "api-key"}
+	policy, err := client.UpdatePolicy(context.Background(), OktaPolicy{
+		ID:   "00p1password",
+		Type: "PASSWORD",
+		Name: "Staff",
+		Conditions: &OktaPolicyConditions{
+			People:       &OktaPolicyPeopleCondition{Groups: OktaInclude{Include: []string{"00g1staff"}}},
+			AuthProvider: &OktaAuthProviderCondition{Provider: "OKTA"},
+		},
+		Settings: &OktaPolicySettings{
+			Password: &OktaPasswordSettings{
+				Complexity: OktaPasswordComplexity{MinLength: 12, ExcludeAttributes: []string{}},
+				Lockout:    OktaPasswordLockout{MaxAttempts: 5},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if policy.Settings.Password.Complexity.MinLength != 12 || len(policy.Settings.Password.Complexity.ExcludeAttributes) != 0 {
+		t.Fatalf("expected the complexity to be updated, got %+v", policy.Settings.Password.Complexity)
+	}
+
+	if groups := policy.Conditions.People.Groups.Include; len(groups) != 1 || groups[0] != "00g1staff" {
+		t.Fatalf("expected the groups to be replaced, got %v", groups)
+	}
+
+	settings := sent["settings"].(map[string]interface{})
+	if _, ok := settings["recovery"]; !ok {
+		t.Fatalf("expected the recovery settings to be kept, got %v", settings)
+	}
+	if _, ok := settings["delegation"]; !ok {
+		t.Fatalf("expected the delegation settings to be kept, got %v", settings)
+	}
+}
+
+func TestOkta_updateMissingPolicy(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("expected no update of a missing policy, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &Okta{HostURL: server.URL, APIKey: "api-key"}
+	if _, err := client.UpdatePolicy(context.Background(), OktaPolicy{ID: "00p1missing", Type: "OKTA_SIGN_ON"}); err == nil {
+		t.Fatalf("expected an error updating a missing policy")
+	}
+}
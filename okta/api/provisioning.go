@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,11 +15,17 @@ import (
 )
 
 type OktaWebClient struct {
-	HostURL  string
-	AdminURL string
-	UserName string
-	Password string
-	OrgID    string
+	HostURL     string
+	AdminURL    string
+	UserName    string
+	Password    string
+	OrgID       string
+	RetryPolicy RetryPolicy
+
+	// The client used for requests to the admin web UI, defaulting to
+	// http.DefaultClient's settings. Each provisioning run gets its own
+	// cookie jar.
+	HTTPClient *http.Client
 }
 
 type OktaAuthResponse struct {
@@ -27,36 +35,101 @@ type OktaAuthResponse struct {
 	Status       string    `json:"status"`
 }
 
-func doRequest(client http.Client, request *http.Request) (*http.Response, error) {
-	resp, err := client.Do(request)
-	if err != nil {
-		return resp, err
+// Validate checks the web client has the admin credentials it needs, which
+// are optional in the provider unless provisioning is managed.
+func (o *OktaWebClient) Validate() error {
+	missing := []string{}
+	if o.AdminURL == "" {
+		missing = append(missing, "okta_admin_url")
+	}
+	if o.UserName == "" {
+		missing = append(missing, "username")
+	}
+	if o.Password == "" {
+		missing = append(missing, "password")
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return resp, fmt.Errorf("Provisioning did not yield successful %d", resp.StatusCode)
+	if len(missing) > 0 {
+		return fmt.Errorf("The Okta Admin WebUI credentials are required to manage provisioning, the provider is missing: %s", strings.Join(missing, ", "))
 	}
 
-	return resp, nil
+	return nil
 }
 
-func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string, secretKey string) error {
+func (o *OktaWebClient) doRequest(client http.Client, request *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := o.RetryPolicy.Do(request.Context(), func(attempt int) (bool, error) {
+		if attempt > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return false, err
+			}
+			request.Body = body
+		}
+
+		if request.GetBody != nil {
+			if body, err := request.GetBody(); err == nil {
+				contents, _ := ioutil.ReadAll(body)
+				traceBody("request", request.Header.Get("Content-Type"), contents)
+			}
+		}
+
+		start := time.Now()
+		var err error
+		resp, err = client.Do(request)
+		if err != nil {
+			traceRequest(request.Method, request.URL, 0, time.Since(start), nil, err)
+			return o.RetryPolicy.ShouldRetryRequest(request.Method, 0, err), err
+		}
+
+		traceRequest(request.Method, request.URL, resp.StatusCode, time.Since(start), resp.Header, nil)
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			err = fmt.Errorf("Provisioning did not yield successful %d", resp.StatusCode)
+			retry := o.RetryPolicy.ShouldRetryRequest(request.Method, resp.StatusCode, err)
+			if retry {
+				resp.Body.Close()
+			}
+			return retry, err
+		}
+
+		return false, nil
+	})
+
+	return resp, err
+}
+
+func (o *OktaWebClient) configureAWSProvisioning(ctx context.Context, appID string, accessKey string, secretKey string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
 	client := http.Client{}
+	if o.HTTPClient != nil {
+		client = *o.HTTPClient
+	}
+
 	log.Println("[DEBUG] Running AWS provisioning method...")
-	authBody := fmt.Sprintf(`{"username":"%s", "password":"%s"}`, o.UserName, o.Password)
+	authBody, err := json.Marshal(map[string]string{
+		"username": o.UserName,
+		"password": o.Password,
+	})
+	if err != nil {
+		return err
+	}
 
 	cookieJar, _ := cookiejar.New(nil)
 	client.Jar = cookieJar
 
 	authUrl := fmt.Sprintf(`%s/api/v1/authn`, o.HostURL)
-	req, _ := http.NewRequest("POST", authUrl, strings.NewReader(authBody))
+	req, _ := http.NewRequestWithContext(ctx, "POST", authUrl, bytes.NewReader(authBody))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
-	res, err := doRequest(client, req)
+	res, err := o.doRequest(client, req)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to POST to authn route....")
-		log.Println(authBody)
+		log.Println(redactJSON(authBody))
 		return err
 	}
 
@@ -68,24 +141,24 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 
 	cookieUrl := fmt.Sprintf("%s/login/sessionCookieRedirect?checkAccountSetupComplete=true&token=%s&redirectUrl=%s/user/notifications", o.HostURL, oktaAuthResponse.SessionToken, o.HostURL)
 
-	req2, _ := http.NewRequest("GET", cookieUrl, nil)
+	req2, _ := http.NewRequestWithContext(ctx, "GET", cookieUrl, nil)
 	req2.Header.Set("Content-Type", "application/json")
 	req2.Header.Set("Accept", "application/json")
 
-	_, err = doRequest(client, req2)
+	_, err = o.doRequest(client, req2)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to GET to sessionCookieRedirect route....")
-		log.Println(cookieUrl)
+		log.Println(redactURL(cookieUrl))
 		return err
 	}
 
 	// ---------------
 	userHomeUrl := fmt.Sprintf("%s/app/UserHome", o.HostURL)
-	req3, _ := http.NewRequest("GET", userHomeUrl, nil)
+	req3, _ := http.NewRequestWithContext(ctx, "GET", userHomeUrl, nil)
 	req3.Header.Set("Content-Type", "application/json")
 	req3.Header.Set("Accept", "application/json")
 
-	_, err = doRequest(client, req3)
+	_, err = o.doRequest(client, req3)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to GET to userHomeUrl route....")
 		log.Println(userHomeUrl)
@@ -94,11 +167,11 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 
 	// ---------------
 	adminEntryUrl := fmt.Sprintf("%s/home/admin-entry", o.HostURL)
-	req4, _ := http.NewRequest("GET", adminEntryUrl, nil)
+	req4, _ := http.NewRequestWithContext(ctx, "GET", adminEntryUrl, nil)
 	req4.Header.Set("Content-Type", "application/json")
 	req4.Header.Set("Accept", "application/json")
 
-	_, err = doRequest(client, req4)
+	_, err = o.doRequest(client, req4)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to GET to admin-entry route....")
 		log.Println(adminEntryUrl)
@@ -107,11 +180,11 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 
 	// ---------------
 	adminSsoUrl := fmt.Sprintf("%s/admin/sso/oidc-entry", o.AdminURL)
-	req5, _ := http.NewRequest("GET", adminSsoUrl, nil)
+	req5, _ := http.NewRequestWithContext(ctx, "GET", adminSsoUrl, nil)
 	req5.Header.Set("Content-Type", "application/json")
 	req5.Header.Set("Accept", "application/json")
 
-	_, err = doRequest(client, req5)
+	_, err = o.doRequest(client, req5)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to GET to admin sso oidc-entry route....")
 		log.Println(adminSsoUrl)
@@ -120,11 +193,11 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 
 	// ---------------
 	dashboardUrl := fmt.Sprintf("%s/admin/dashboard", o.AdminURL)
-	req6, _ := http.NewRequest("GET", dashboardUrl, nil)
+	req6, _ := http.NewRequestWithContext(ctx, "GET", dashboardUrl, nil)
 	req6.Header.Set("Content-Type", "application/json")
 	req6.Header.Set("Accept", "application/json")
 
-	dashResp, err := doRequest(client, req6)
+	dashResp, err := o.doRequest(client, req6)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to GET to admin dashboard route....")
 		log.Println(dashboardUrl)
@@ -153,15 +226,15 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 		updateAppData.Add("enabled", "true")
 	}
 
-	req7, _ := http.NewRequest("POST", appUpdateUrl, strings.NewReader(updateAppData.Encode()))
+	req7, _ := http.NewRequestWithContext(ctx, "POST", appUpdateUrl, strings.NewReader(updateAppData.Encode()))
 	req7.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req7.Header.Set("Accept", "application/json")
 
 	//here we are not successfully updating it
-	_, err = doRequest(client, req7)
+	_, err = o.doRequest(client, req7)
 	if err != nil {
 		log.Println("[ERROR] AWS provisioning: Failed to POST to app update route....")
-		log.Println(updateAppData.Encode())
+		log.Println(redactForm(updateAppData))
 		return err
 	}
 
@@ -169,10 +242,10 @@ func (o *OktaWebClient) configureAWSProvisioning(appID string, accessKey string,
 	return nil
 }
 
-func (o *OktaWebClient) RevokeAWSProvisioning(appID string) error {
-	return o.configureAWSProvisioning(appID, "", "")
+func (o *OktaWebClient) RevokeAWSProvisioning(ctx context.Context, appID string) error {
+	return o.configureAWSProvisioning(ctx, appID, "", "")
 }
 
-func (o *OktaWebClient) SetAWSProvisioning(appID string, accessKey string, secretKey string) error {
-	return o.configureAWSProvisioning(appID, accessKey, secretKey)
+func (o *OktaWebClient) SetAWSProvisioning(ctx context.Context, appID string, accessKey string, secretKey string) error {
+	return o.configureAWSProvisioning(ctx, appID, accessKey, secretKey)
 }
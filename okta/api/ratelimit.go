@@ -0,0 +1,102 @@
+package api
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var oktaIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{20}$`)
+
+// rateLimits tracks the rate limits Okta reports for each endpoint. It is
+// shared by every request made through a client, so once a limit is used
+// up, requests to that endpoint wait for it to reset rather than fail.
+type rateLimits struct {
+	mu      sync.Mutex
+	buckets map[string]rateLimit
+}
+
+type rateLimit struct {
+	remaining int
+	reset     time.Time
+}
+
+func newRateLimits() *rateLimits {
+	return &rateLimits{
+		buckets: map[string]rateLimit{},
+	}
+}
+
+// rateLimitBucket groups requests the way Okta applies its limits, by
+// method and endpoint regardless of the IDs in the path.
+func rateLimitBucket(method string, rawURL string) string {
+	path := rawURL
+	if parsed, err := url.Parse(rawURL); err == nil {
+		path = parsed.Path
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if oktaIDPattern.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+
+	return method + " /" + strings.Join(segments, "/")
+}
+
+// wait blocks until the bucket has requests remaining, or the context is
+// done.
+func (l *rateLimits) wait(ctx context.Context, bucket string) error {
+	l.mu.Lock()
+	limit, ok := l.buckets[bucket]
+	l.mu.Unlock()
+
+	if !ok || limit.remaining > 0 {
+		return nil
+	}
+
+	delay := time.Until(limit.reset)
+	if delay <= 0 {
+		return nil
+	}
+
+	log.Printf("[DEBUG] Rate limit for %s is used up, waiting %s for it to reset", bucket, delay)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// update records the rate limit reported in the response headers.
+func (l *rateLimits) update(bucket string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("x-rate-limit-remaining"))
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("x-rate-limit-reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buckets[bucket] = rateLimit{
+		remaining: remaining,
+		reset:     time.Unix(reset, 0),
+	}
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRateLimitBucket(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/apps/0oa1a2b3c4d5e6f7g8h9":                            "GET /api/v1/apps/{id}",
+		"api/v1/apps/0oa1a2b3c4d5e6f7g8h9":                             "GET /api/v1/apps/{id}",
+		"/api/v1/apps/0oa1a2b3c4d5e6f7g8h9/users?limit=500":            "GET /api/v1/apps/{id}/users",
+		"/api/v1/apps/0oa1a2b3c4d5e6f7g8h9/users/00u1a2b3c4d5e6f7g8h9": "GET /api/v1/apps/{id}/users/{id}",
+		"/api/v1/users?q=jdoe":                                         "GET /api/v1/users",
+	}
+
+	for url, expected := range cases {
+		if bucket := rateLimitBucket("GET", url); bucket != expected {
+			t.Fatalf("expected bucket %q for %s, got %q", expected, url, bucket)
+		}
+	}
+}
+
+func TestRateLimits_waitsForReset(t *testing.T) {
+	limits := newRateLimits()
+	bucket := "GET /api/v1/apps"
+
+	if err := limits.wait(context.Background(), bucket); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	header := http.Header{}
+	header.Set("x-rate-limit-remaining", "0")
+	header.Set("x-rate-limit-reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+	limits.update(bucket, header)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limits.wait(ctx, bucket); err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for the rate limit to reset, got %v", err)
+	}
+
+	if err := limits.wait(context.Background(), "GET /api/v1/users"); err != nil {
+		t.Fatalf("expected other buckets not to wait, got %s", err)
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// Keys whose values must never be written to the logs. Matching is case
+// insensitive so both form fields and JSON properties are covered.
+var sensitiveKeys = []string{
+	"_xsrftoken",
+	"access_token",
+	"accesskeyum",
+	"authorization",
+	"baseurl",
+	"client_assertion",
+	"password",
+	"secretkeyum",
+	"sessiontoken",
+	"statetoken",
+	"token",
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if key == sensitive {
+			return true
+		}
+	}
+	return false
+}
+
+// redactForm encodes the form values with any credentials replaced.
+func redactForm(values url.Values) string {
+	redacted := url.Values{}
+	for key, value := range values {
+		if isSensitiveKey(key) {
+			redacted[key] = []string{redactedValue}
+			continue
+		}
+		redacted[key] = value
+	}
+	return redacted.Encode()
+}
+
+// redactURL returns the URL with any credentials in the query replaced.
+func redactURL(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return redactedValue
+	}
+
+	parsed.RawQuery = redactForm(parsed.Query())
+	return parsed.String()
+}
+
+// redactJSON returns the JSON document with any credentials replaced. A
+// body that cannot be parsed is redacted entirely, as we can't tell what
+// it contains.
+func redactJSON(body []byte) string {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return redactedValue
+	}
+
+	redacted, err := json.Marshal(redactJSONValue(document))
+	if err != nil {
+		return redactedValue
+	}
+	return string(redacted)
+}
+
+func redactJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if isSensitiveKey(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactJSONValue(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = redactJSONValue(child)
+		}
+	}
+	return value
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// The helpers for resources Okta manages the same way: read, sent as JSON
+// and deleted at their URL, and activated through their lifecycle.
+
+// getResource reads the resource at the URL into result, and
+// reports whether it was found.
+func (o *Okta) getResource(ctx context.Context, url string, result interface{}) (bool, error) {
+	restClient := o.GetRestClient()
+
+	req := restClient.R().SetBody("").SetResult(result)
+
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
+	if err != nil {
+		return false, err
+	}
+
+	return resp.StatusCode() != http.StatusNotFound, nil
+}
+
+// sendResource sends the resource to the URL, and reads what Okta
+// returns into result.
+func (o *Okta) sendResource(ctx context.Context, method string, url string, resource interface{}, result interface{}) error {
+	restClient := o.GetRestClient()
+
+	body, err := json.Marshal(resource)
+	if err != nil {
+		return err
+	}
+
+	req := restClient.R().SetBody(string(body)).SetResult(result)
+
+	_, err = o.execute(ctx, req, method, url)
+	return err
+}
+
+func (o *Okta) deleteResource(ctx context.Context, url string) error {
+	restClient := o.GetRestClient()
+
+	req := restClient.R().SetBody("")
+
+	_, err := o.execute(ctx, req, resty.MethodDelete, url)
+	return err
+}
+
+// setLifecycle activates or deactivates the resource at the URL.
+func (o *Okta) setLifecycle(ctx context.Context, url string, active bool) error {
+	restClient := o.GetRestClient()
+
+	action := "deactivate"
+	if active {
+		action = "activate"
+	}
+
+	req := restClient.R().SetBody("")
+
+	_, err := o.execute(ctx, req, resty.MethodPost, url+"/lifecycle/"+action)
+	return err
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+const DefaultRetryMinimumWait = 30 * time.Second
+const DefaultRetryMaximumWait = 15 * time.Minute
+
+// RetryPolicy describes how requests to Okta are retried. It is shared by
+// the API client, the admin web client and the resources waiting on Okta to
+// settle. Zero values fall back to the defaults, other than MaxRetries where
+// zero disables retrying.
+//
+// Requests that aren't idempotent, such as a POST creating an app, are only
+// retried when rate limited, as Okta may have applied them before failing.
+// NonIdempotent opts them in to the other retries too.
+type RetryPolicy struct {
+	MaxRetries       int
+	MinWait          time.Duration
+	MaxWait          time.Duration
+	Jitter           bool
+	StatusCodes      []int
+	ConnectionErrors bool
+	NonIdempotent    bool
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:  25,
+		MinWait:     DefaultRetryMinimumWait,
+		MaxWait:     DefaultRetryMaximumWait,
+		Jitter:      true,
+		StatusCodes: []int{http.StatusTooManyRequests},
+	}
+}
+
+// Backoff returns how long to wait before the given retry attempt, starting
+// at 1. The wait grows exponentially from MinWait up to MaxWait, and when
+// jitter is enabled a random amount of up to half the wait is taken off.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	min := p.MinWait
+	if min <= 0 {
+		min = DefaultRetryMinimumWait
+	}
+
+	max := p.MaxWait
+	if max <= 0 {
+		max = DefaultRetryMaximumWait
+	}
+
+	if max < min {
+		max = min
+	}
+
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	wait := math.Min(float64(max), float64(min)*math.Exp2(float64(attempt-1)))
+	if p.Jitter {
+		half := int64(wait / 2)
+		if half > 0 {
+			wait = float64(half + rand.Int63n(half))
+		}
+	}
+
+	return time.Duration(math.Max(wait, float64(min)))
+}
+
+// ShouldRetry reports whether a request that failed with the given status
+// code and error should be attempted again.
+func (p RetryPolicy) ShouldRetry(status int, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if status == 0 {
+		return p.ConnectionErrors && isConnectionError(err)
+	}
+
+	codes := p.StatusCodes
+	if codes == nil {
+		codes = []int{http.StatusTooManyRequests}
+	}
+
+	for _, code := range codes {
+		if code == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ShouldRetryRequest is ShouldRetry for a request made with the given
+// method. A request that isn't idempotent is only retried when it was rate
+// limited, unless the policy opts in to retrying it.
+func (p RetryPolicy) ShouldRetryRequest(method string, status int, err error) bool {
+	if !p.ShouldRetry(status, err) {
+		return false
+	}
+
+	return status == http.StatusTooManyRequests || p.NonIdempotent || isIdempotent(method)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Do runs the operation until it succeeds, it reports that its error isn't
+// worth retrying, or the retries run out. Attempts are numbered from 1. No
+// further attempts are made once the context is done.
+func (p RetryPolicy) Do(ctx context.Context, operation func(attempt int) (bool, error)) error {
+	for attempt := 1; ; attempt++ {
+		retry, err := operation(attempt)
+		if err == nil {
+			return nil
+		}
+
+		if ctx.Err() != nil {
+			return deadlineError(ctx, attempt, err)
+		}
+
+		if !retry || attempt > p.MaxRetries {
+			return err
+		}
+
+		wait := p.Backoff(attempt)
+		log.Printf("[DEBUG] Retrying in %s after attempt %d failed: %s", wait, attempt, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return deadlineError(ctx, attempt, err)
+		}
+	}
+}
+
+func deadlineError(ctx context.Context, attempt int, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Deadline exceeded after %d attempt(s), last error: %s", attempt, err)
+	}
+	return fmt.Errorf("Cancelled after %d attempt(s), last error: %s", attempt, err)
+}
+
+func isConnectionError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_ShouldRetryRequest(t *testing.T) {
+	policy := RetryPolicy{
+		StatusCodes:      []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+		ConnectionErrors: true,
+	}
+	err := fmt.Errorf("request failed")
+
+	cases := []struct {
+		method        string
+		status        int
+		err           error
+		nonIdempotent bool
+		expected      bool
+	}{
+		{http.MethodGet, http.StatusServiceUnavailable, err, false, true},
+		{http.MethodPut, http.StatusServiceUnavailable, err, false, true},
+		{http.MethodDelete, 0, io.EOF, false, true},
+		{http.MethodGet, http.StatusBadRequest, err, false, false},
+		{http.MethodGet, http.StatusServiceUnavailable, nil, false, false},
+		{http.MethodPost, http.StatusTooManyRequests, err, false, true},
+		{http.MethodPost, http.StatusServiceUnavailable, err, false, false},
+		{http.MethodPost, 0, io.EOF, false, false},
+		{http.MethodPost, http.StatusServiceUnavailable, err, true, true},
+		{http.MethodPost, 0, io.EOF, true, true},
+	}
+
+	for _, c := range cases {
+		policy.NonIdempotent = c.nonIdempotent
+		if retry := policy.ShouldRetryRequest(c.method, c.status, c.err); retry != c.expected {
+			t.Fatalf("expected %s %d (non idempotent %t) to retry %t, got %t", c.method, c.status, c.nonIdempotent, c.expected, retry)
+		}
+	}
+}
+
+func TestOkta_retriesOnlyIdempotentRequests(t *testing.T) {
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts[r.Method]++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := &Okta{
+		HostURL: server.URL,
+		APIKey:  "api-key",
+		RetryPolicy: RetryPolicy{
+			MaxRetries:  2,
+			MinWait:     time.Millisecond,
+			MaxWait:     time.Millisecond,
+			StatusCodes: []int{http.StatusServiceUnavailable},
+		},
+	}
+
+	if _, err := client.GetApplication(context.Background(), "0oa1"); err == nil {
+		t.Fatalf("expected an error when Okta is unavailable")
+	}
+
+	if _, err := client.CreateApplication(context.Background(), NewAwsApplication("ACME-AwsAccount", "")); err == nil {
+		t.Fatalf("expected an error when Okta is unavailable")
+	}
+
+	if attempts[http.MethodGet] != 3 {
+		t.Fatalf("expected the GET to be retried twice, got %d attempts", attempts[http.MethodGet])
+	}
+
+	if attempts[http.MethodPost] != 1 {
+		t.Fatalf("expected the POST not to be retried, got %d attempts", attempts[http.MethodPost])
+	}
+}
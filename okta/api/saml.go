@@ -0,0 +1,162 @@
+package api
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	SAMLBindingHTTPRedirect = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect"
+	SAMLBindingHTTPPost     = "urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST"
+)
+
+// SAMLMetadata is the part of an app's IdP metadata that service providers
+// need to trust Okta.
+type SAMLMetadata struct {
+	EntityID          string
+	SSORedirectURL    string
+	SSOPostURL        string
+	SLOURL            string
+	Certificate       string
+	FingerprintSHA1   string
+	FingerprintSHA256 string
+	NotBefore         time.Time
+	NotAfter          time.Time
+}
+
+type samlEntityDescriptor struct {
+	EntityID string `xml:"entityID,attr"`
+	IDP      struct {
+		KeyDescriptors []struct {
+			Use         string `xml:"use,attr"`
+			Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+		} `xml:"KeyDescriptor"`
+		SingleLogoutServices []samlEndpoint `xml:"SingleLogoutService"`
+		SingleSignOnServices []samlEndpoint `xml:"SingleSignOnService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+type samlServiceProviderDescriptor struct {
+	EntityID string `xml:"entityID,attr"`
+	SP       struct {
+		AssertionConsumerServices []samlEndpoint `xml:"AssertionConsumerService"`
+	} `xml:"SPSSODescriptor"`
+}
+
+type samlEndpoint struct {
+	Binding  string `xml:"Binding,attr"`
+	Location string `xml:"Location,attr"`
+}
+
+// ParseSAMLMetadata reads the entity ID, endpoints and signing certificate
+// from an app's SAML metadata document.
+func ParseSAMLMetadata(document string) (*SAMLMetadata, error) {
+	descriptor := samlEntityDescriptor{}
+	if err := xml.Unmarshal([]byte(document), &descriptor); err != nil {
+		return nil, fmt.Errorf("Could not parse the SAML metadata: %s", err)
+	}
+
+	metadata := &SAMLMetadata{
+		EntityID: descriptor.EntityID,
+	}
+
+	for _, endpoint := range descriptor.IDP.SingleSignOnServices {
+		switch endpoint.Binding {
+		case SAMLBindingHTTPRedirect:
+			metadata.SSORedirectURL = endpoint.Location
+		case SAMLBindingHTTPPost:
+			metadata.SSOPostURL = endpoint.Location
+		}
+	}
+
+	if len(descriptor.IDP.SingleLogoutServices) > 0 {
+		metadata.SLOURL = descriptor.IDP.SingleLogoutServices[0].Location
+	}
+
+	for _, key := range descriptor.IDP.KeyDescriptors {
+		if key.Use != "" && key.Use != "signing" {
+			continue
+		}
+
+		encoded := strings.Join(strings.Fields(key.Certificate), "")
+		if encoded == "" {
+			continue
+		}
+
+		der, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("Could not decode the SAML signing certificate: %s", err)
+		}
+
+		certificate, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse the SAML signing certificate: %s", err)
+		}
+
+		sha1Sum := sha1.Sum(der)
+		sha256Sum := sha256.Sum256(der)
+
+		metadata.Certificate = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		metadata.FingerprintSHA1 = fingerprint(sha1Sum[:])
+		metadata.FingerprintSHA256 = fingerprint(sha256Sum[:])
+		metadata.NotBefore = certificate.NotBefore
+		metadata.NotAfter = certificate.NotAfter
+		break
+	}
+
+	return metadata, nil
+}
+
+// fingerprint formats a certificate digest the way the Okta console shows
+// it, as colon separated upper case hex.
+func fingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(parts, ":")
+}
+
+// SAMLServiceProvider is the part of a service provider's metadata an app
+// needs to send it assertions.
+type SAMLServiceProvider struct {
+	EntityID string
+	ACSURL   string
+}
+
+// ParseSAMLServiceProviderMetadata reads the entity ID and assertion consumer
+// service of a service provider, such as AWS IAM Identity Center, from its
+// SAML metadata document. The HTTP-POST binding is preferred, as it is the
+// one Okta sends assertions with.
+func ParseSAMLServiceProviderMetadata(document string) (*SAMLServiceProvider, error) {
+	descriptor := samlServiceProviderDescriptor{}
+	if err := xml.Unmarshal([]byte(document), &descriptor); err != nil {
+		return nil, fmt.Errorf("Could not parse the SAML metadata: %s", err)
+	}
+
+	provider := &SAMLServiceProvider{
+		EntityID: descriptor.EntityID,
+	}
+
+	for _, endpoint := range descriptor.SP.AssertionConsumerServices {
+		if provider.ACSURL == "" || endpoint.Binding == SAMLBindingHTTPPost {
+			provider.ACSURL = endpoint.Location
+		}
+		if endpoint.Binding == SAMLBindingHTTPPost {
+			break
+		}
+	}
+
+	if provider.EntityID == "" || provider.ACSURL == "" {
+		return nil, fmt.Errorf("The SAML metadata is not of a service provider, it has no entity ID or assertion consumer service")
+	}
+
+	return provider, nil
+}
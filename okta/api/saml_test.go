@@ -0,0 +1,133 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testSAMLMetadata returns IdP metadata the way Okta lays it out, signed
+// with a freshly generated certificate.
+func testSAMLMetadata(t *testing.T, notBefore time.Time, notAfter time.Time) (string, []byte) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	encoded := base64.StdEncoding.EncodeToString(der)
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="http://www.okta.com/exk1example">
+  <md:IDPSSODescriptor WantAuthnRequestsSigned="false" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="signing">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data>
+          <ds:X509Certificate>%s
+%s</ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:SingleLogoutService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://example.okta.com/app/amazon_aws/exk1example/slo/saml"/>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:unspecified</md:NameIDFormat>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://example.okta.com/app/amazon_aws/exk1example/sso/saml"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://example.okta.com/app/amazon_aws/exk1example/sso/saml?redirect"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`, encoded[:64], encoded[64:]), der
+}
+
+func TestParseSAMLMetadata(t *testing.T) {
+	notBefore := time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC)
+	notAfter := time.Date(2030, 3, 2, 0, 0, 0, 0, time.UTC)
+	document, der := testSAMLMetadata(t, notBefore, notAfter)
+
+	metadata, err := ParseSAMLMetadata(document)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if metadata.EntityID != "http://www.okta.com/exk1example" {
+		t.Fatalf("unexpected entity ID %q", metadata.EntityID)
+	}
+
+	if metadata.SSOPostURL != "https://example.okta.com/app/amazon_aws/exk1example/sso/saml" ||
+		metadata.SSORedirectURL != "https://example.okta.com/app/amazon_aws/exk1example/sso/saml?redirect" ||
+		metadata.SLOURL != "https://example.okta.com/app/amazon_aws/exk1example/slo/saml" {
+		t.Fatalf("unexpected endpoints %+v", metadata)
+	}
+
+	if !strings.HasPrefix(metadata.Certificate, "-----BEGIN CERTIFICATE-----") {
+		t.Fatalf("expected a PEM certificate, got %q", metadata.Certificate)
+	}
+
+	sum := sha256.Sum256(der)
+	if metadata.FingerprintSHA256 != fingerprint(sum[:]) || len(strings.Split(metadata.FingerprintSHA1, ":")) != 20 {
+		t.Fatalf("unexpected fingerprints %q and %q", metadata.FingerprintSHA1, metadata.FingerprintSHA256)
+	}
+
+	if !metadata.NotBefore.Equal(notBefore) || !metadata.NotAfter.Equal(notAfter) {
+		t.Fatalf("unexpected validity %s to %s", metadata.NotBefore, metadata.NotAfter)
+	}
+}
+
+func TestParseSAMLMetadata_invalid(t *testing.T) {
+	if _, err := ParseSAMLMetadata("not xml"); err == nil {
+		t.Fatalf("expected an error for a document that isn't XML")
+	}
+
+	document := `<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata"><md:IDPSSODescriptor><md:KeyDescriptor use="signing"><ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#"><ds:X509Data><ds:X509Certificate>bm90IGEgY2VydGlmaWNhdGU=</ds:X509Certificate></ds:X509Data></ds:KeyInfo></md:KeyDescriptor></md:IDPSSODescriptor></md:EntityDescriptor>`
+	if _, err := ParseSAMLMetadata(document); err == nil {
+		t.Fatalf("expected an error for an invalid certificate")
+	}
+}
+
+func TestParseSAMLServiceProviderMetadata(t *testing.T) {
+	document := `<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://us-east-1.signin.aws.amazon.com/platform/saml/d-1234567890">
+  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress</md:NameIDFormat>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://us-east-1.signin.aws.amazon.com/platform/saml/acs/redirect" index="1"/>
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://us-east-1.signin.aws.amazon.com/platform/saml/acs/d-1234567890" index="0"/>
+  </md:SPSSODescriptor>
+</md:EntityDescriptor>`
+
+	provider, err := ParseSAMLServiceProviderMetadata(document)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if provider.EntityID != "https://us-east-1.signin.aws.amazon.com/platform/saml/d-1234567890" {
+		t.Fatalf("unexpected entity ID %q", provider.EntityID)
+	}
+
+	if provider.ACSURL != "https://us-east-1.signin.aws.amazon.com/platform/saml/acs/d-1234567890" {
+		t.Fatalf("expected the HTTP-POST assertion consumer service, got %q", provider.ACSURL)
+	}
+
+	if _, err := ParseSAMLServiceProviderMetadata("not xml"); err == nil {
+		t.Fatalf("expected an error for a document that isn't XML")
+	}
+
+	idp, _ := testSAMLMetadata(t, time.Now(), time.Now().Add(time.Hour))
+	if _, err := ParseSAMLServiceProviderMetadata(idp); err == nil {
+		t.Fatalf("expected an error for IdP metadata")
+	}
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// OktaProvisioningConnection is how an app connects to the SCIM server it
+// provisions users to. Okta never returns the token once it is set.
+type OktaProvisioningConnection struct {
+	AuthScheme string                            `json:"authScheme,omitempty"`
+	Status     string                            `json:"status,omitempty"`
+	BaseURL    string                            `json:"baseUrl,omitempty"`
+	Profile    OktaProvisioningConnectionProfile `json:"profile"`
+}
+
+type OktaProvisioningConnectionProfile struct {
+	AuthScheme string `json:"authScheme"`
+	Token      string `json:"token,omitempty"`
+}
+
+// OktaProvisioningFeature is the USER_PROVISIONING feature of an app, which
+// controls what Okta pushes to the SCIM server.
+type OktaProvisioningFeature struct {
+	Name         string                       `json:"name,omitempty"`
+	Status       string                       `json:"status,omitempty"`
+	Capabilities OktaProvisioningCapabilities `json:"capabilities"`
+}
+
+type OktaProvisioningCapabilities struct {
+	Create OktaProvisioningCreate `json:"create"`
+	Update OktaProvisioningUpdate `json:"update"`
+}
+
+type OktaProvisioningCreate struct {
+	LifecycleCreate OktaCapabilityStatus `json:"lifecycleCreate"`
+}
+
+type OktaProvisioningUpdate struct {
+	LifecycleDeactivate OktaCapabilityStatus `json:"lifecycleDeactivate"`
+	Profile             OktaCapabilityStatus `json:"profile"`
+}
+
+type OktaCapabilityStatus struct {
+	Status string `json:"status"`
+}
+
+// CapabilityStatus returns the status Okta takes for a capability that is
+// enabled or not.
+func CapabilityStatus(enabled bool) OktaCapabilityStatus {
+	if enabled {
+		return OktaCapabilityStatus{Status: "ENABLED"}
+	}
+	return OktaCapabilityStatus{Status: "DISABLED"}
+}
+
+// Enabled reports whether the capability is enabled.
+func (s OktaCapabilityStatus) Enabled() bool {
+	return s.Status == "ENABLED"
+}
+
+// GetProvisioningConnection returns the app's connection to its SCIM server,
+// or nil when the app doesn't support provisioning.
+func (o *Okta) GetProvisioningConnection(ctx context.Context, appID string) (*OktaProvisioningConnection, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/connections/default", appID)
+	req := restClient.R().SetBody("").SetResult(&OktaProvisioningConnection{})
+
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	status := resp.StatusCode()
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	response := resp.Result().(*OktaProvisioningConnection)
+	if response == nil {
+		return nil, nil
+	}
+
+	return response, nil
+}
+
+// SetProvisioningConnection points the app at the SCIM server, which it
+// authenticates to with the bearer token, and activates the connection.
+func (o *Okta) SetProvisioningConnection(ctx context.Context, appID string, baseURL string, token string) (*OktaProvisioningConnection, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/connections/default?activate=true", appID)
+
+	body, err := json.Marshal(OktaProvisioningConnection{
+		BaseURL: baseURL,
+		Profile: OktaProvisioningConnectionProfile{
+			AuthScheme: "TOKEN",
+			Token:      token,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req := restClient.R().SetBody(string(body)).SetResult(&OktaProvisioningConnection{})
+
+	resp, err := o.execute(ctx, req, resty.MethodPost, url)
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Result().(*OktaProvisioningConnection)
+	return result, nil
+}
+
+// DeactivateProvisioningConnection stops the app provisioning users to its
+// SCIM server.
+func (o *Okta) DeactivateProvisioningConnection(ctx context.Context, appID string) error {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/connections/default/lifecycle/deactivate", appID)
+	req := restClient.R().SetBody("")
+
+	_, err := o.execute(ctx, req, resty.MethodPost, url)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetProvisioningFeature returns the app's USER_PROVISIONING feature, or nil
+// when provisioning isn't enabled.
+func (o *Okta) GetProvisioningFeature(ctx context.Context, appID string) (*OktaProvisioningFeature, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/features/USER_PROVISIONING", appID)
+	req := restClient.R().SetBody("").SetResult(&OktaProvisioningFeature{})
+
+	resp, err := o.execute(ctx, req, resty.MethodGet, url)
+	if err != nil {
+		return nil, err
+	}
+
+	status := resp.StatusCode()
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+
+	response := resp.Result().(*OktaProvisioningFeature)
+	if response == nil {
+		return nil, nil
+	}
+
+	return response, nil
+}
+
+// UpdateProvisioningFeature sets which changes the app pushes to its SCIM
+// server. The connection must be active first.
+func (o *Okta) UpdateProvisioningFeature(ctx context.Context, appID string, capabilities OktaProvisioningCapabilities) (*OktaProvisioningFeature, error) {
+	restClient := o.GetRestClient()
+
+	url := fmt.Sprintf("/api/v1/apps/%s/features/USER_PROVISIONING", appID)
+
+	body, err := json.Marshal(capabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	req := restClient.R().SetBody(string(body)).SetResult(&OktaProvisioningFeature{})
+
+	resp, err := o.execute(ctx, req, resty.MethodPut, url)
+	if err != nil {
+		return nil, err
+	}
+
+	result := resp.Result().(*OktaProvisioningFeature)
+	return result, nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// The response headers worth keeping when tracing requests, to correlate
+// them with the Okta system log and see how close they are to a rate limit.
+var tracedHeaders = []string{
+	"X-Okta-Request-Id",
+	"X-Rate-Limit-Limit",
+	"X-Rate-Limit-Remaining",
+	"X-Rate-Limit-Reset",
+}
+
+// traceRequest logs a request made to Okta, visible with TF_LOG=DEBUG. Any
+// credentials in the URL are redacted, and headers other than the request
+// ID and rate limits are left out.
+func traceRequest(method string, requestURL *url.URL, status int, duration time.Duration, header http.Header, err error) {
+	target := ""
+	if requestURL != nil {
+		target = redactURL(requestURL.RequestURI())
+	}
+
+	var fields bytes.Buffer
+	for _, name := range tracedHeaders {
+		if value := header.Get(name); value != "" {
+			fmt.Fprintf(&fields, " %s=%s", name, value)
+		}
+	}
+
+	if err != nil && status == 0 {
+		log.Printf("[DEBUG] Okta request: %s %s failed after %s: %s", method, target, duration, err)
+		return
+	}
+
+	log.Printf("[DEBUG] Okta request: %s %s status=%d duration=%s%s", method, target, status, duration, fields.String())
+}
+
+// traceBody logs a request or response body, visible with TF_LOG=TRACE.
+// JSON and form bodies have their credentials redacted, anything else is
+// left out entirely.
+func traceBody(kind string, contentType string, body []byte) {
+	if len(body) == 0 {
+		return
+	}
+
+	redacted := redactedValue
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")), bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")):
+		redacted = redactJSON(body)
+	case contentType == "application/x-www-form-urlencoded":
+		if values, err := url.ParseQuery(string(body)); err == nil {
+			redacted = redactForm(values)
+		}
+	}
+
+	log.Printf("[TRACE] Okta %s body: %s", kind, redacted)
+}
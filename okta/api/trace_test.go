@@ -0,0 +1,41 @@
+package api
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTrace_redactsCredentials(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	requestURL, _ := url.Parse("https://example.okta.com/login/sessionCookieRedirect?token=session-token&redirectUrl=/")
+	header := http.Header{}
+	header.Set("X-Okta-Request-Id", "request-id")
+	header.Set("X-Rate-Limit-Remaining", "42")
+	header.Set("Set-Cookie", "sid=session-cookie")
+
+	traceRequest("GET", requestURL, 302, 15*time.Millisecond, header, nil)
+	traceBody("request", "application/json", []byte(`{"username":"admin","password":"P@ssw0rd!"}`))
+	traceBody("request", "application/x-www-form-urlencoded", []byte("accessKeyUM=AKIAEXAMPLE&secretKeyUM=secret&_enabled=on"))
+
+	logged := output.String()
+	for _, secret := range []string{"session-token", "session-cookie", "P@ssw0rd!", "AKIAEXAMPLE", "secret&"} {
+		if strings.Contains(logged, secret) {
+			t.Fatalf("expected %q to be redacted from:\n%s", secret, logged)
+		}
+	}
+
+	for _, expected := range []string{"GET /login/sessionCookieRedirect", "status=302", "X-Okta-Request-Id=request-id", "X-Rate-Limit-Remaining=42", "_enabled=on"} {
+		if !strings.Contains(logged, expected) {
+			t.Fatalf("expected %q to be logged in:\n%s", expected, logged)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package okta
+
+import (
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// The settings shared by every kind of app. Most are optional and computed,
+// so those left out of the configuration keep whatever is set in Okta. The
+// redirect URLs and user name suffix can't be told apart from an empty value
+// when computed, so they are cleared when left out instead.
+func appSettingsSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["hide_ios"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Computed:    true,
+		Description: "Do not display the app icon in the Okta Mobile app",
+	}
+	s["hide_web"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Computed:    true,
+		Description: "Do not display the app icon on the end user dashboard",
+	}
+	s["auto_submit_toolbar"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Computed:    true,
+		Description: "Log in automatically when the user lands on the login page",
+	}
+	s["self_service"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Optional:    true,
+		Computed:    true,
+		Description: "Allow users to request the app themselves",
+	}
+	s["error_redirect_url"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Where users are sent when the app fails to sign them in. Cleared when left out",
+	}
+	s["login_redirect_url"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Where users are sent to sign in to the app. Cleared when left out",
+	}
+	s["user_name_template"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		Description: "The expression for the user's name in the app, such as ${source.email}",
+	}
+	s["user_name_template_type"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.StringInSlice([]string{"NONE", "BUILT_IN", "CUSTOM"}, false),
+	}
+	s["user_name_template_suffix"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "The suffix appended to the user's name. Cleared when left out",
+	}
+	s["user_name_template_push_status"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.StringInSlice([]string{"PUSH", "DONT_PUSH"}, false),
+		Description:  "Whether a change to the user's name is pushed to the app",
+	}
+
+	return s
+}
+
+// applyAppSettings sets the app's visibility, accessibility and user name
+// template from the resource configuration.
+func applyAppSettings(d *schema.ResourceData, app *api.OktaApplicationContents) {
+	app.Visibility = &api.OktaApplicationVisibility{
+		AutoSubmitToolbar: d.Get("auto_submit_toolbar").(bool),
+		Hide: api.OktaApplicationHide{
+			IOS: d.Get("hide_ios").(bool),
+			Web: d.Get("hide_web").(bool),
+		},
+	}
+
+	app.Accessibility = &api.OktaApplicationAccessibility{
+		SelfService:      d.Get("self_service").(bool),
+		ErrorRedirectURL: api.String(d.Get("error_redirect_url").(string)),
+		LoginRedirectURL: api.String(d.Get("login_redirect_url").(string)),
+	}
+
+	template := &api.OktaApplicationUserNameTemplate{
+		Template:   d.Get("user_name_template").(string),
+		Type:       d.Get("user_name_template_type").(string),
+		Suffix:     api.String(d.Get("user_name_template_suffix").(string)),
+		PushStatus: d.Get("user_name_template_push_status").(string),
+	}
+
+	// The template is only sent when one is set, or when the suffix is being
+	// cleared, as the empty suffix it is sent with is what clears it.
+	if *template.Suffix != "" || d.HasChange("user_name_template_suffix") || template.Template != "" || template.Type != "" || template.PushStatus != "" {
+		if app.Credentials == nil {
+			app.Credentials = &api.OktaApplicationCredentials{}
+		}
+		app.Credentials.UserNameTemplate = template
+	}
+}
+
+func setAppSettings(d *schema.ResourceData, app *api.OktaApplication) {
+	if app.Visibility != nil {
+		d.Set("hide_ios", app.Visibility.Hide.IOS)
+		d.Set("hide_web", app.Visibility.Hide.Web)
+		d.Set("auto_submit_toolbar", app.Visibility.AutoSubmitToolbar)
+	}
+
+	if app.Accessibility != nil {
+		d.Set("self_service", app.Accessibility.SelfService)
+		d.Set("error_redirect_url", api.StringValue(app.Accessibility.ErrorRedirectURL))
+		d.Set("login_redirect_url", api.StringValue(app.Accessibility.LoginRedirectURL))
+	}
+
+	if app.Credentials != nil && app.Credentials.UserNameTemplate != nil {
+		d.Set("user_name_template", app.Credentials.UserNameTemplate.Template)
+		d.Set("user_name_template_type", app.Credentials.UserNameTemplate.Type)
+		d.Set("user_name_template_suffix", api.StringValue(app.Credentials.UserNameTemplate.Suffix))
+		d.Set("user_name_template_push_status", app.Credentials.UserNameTemplate.PushStatus)
+	}
+}
@@ -0,0 +1,154 @@
+package okta
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+// IAM role names, which the role value pattern of an AWS app puts in the
+// role ARNs.
+var iamRoleName = regexp.MustCompile(`^[\w+=,.@-]{1,64}$`)
+
+// Role values that are ARNs already, such as the saml-provider,role pairs of
+// apps that join the roles of all their accounts.
+var iamRoleValueArn = regexp.MustCompile(`^arn:[a-z-]+:iam::\d{12}:(role|saml-provider)/`)
+
+// groupFilterRolePattern returns the pattern the {{role}} capture of an AWS
+// app's group filter matches, or an empty string when it has none.
+func groupFilterRolePattern(groupFilter string) string {
+	start := strings.Index(groupFilter, "(?{{role}}")
+	if start < 0 {
+		return ""
+	}
+
+	depth := 0
+	escaped := false
+	for i := start; i < len(groupFilter); i++ {
+		switch {
+		case escaped:
+			escaped = false
+		case groupFilter[i] == '\\':
+			escaped = true
+		case groupFilter[i] == '(':
+			depth++
+		case groupFilter[i] == ')':
+			depth--
+			if depth == 0 {
+				return groupFilter[start+len("(?{{role}}") : i]
+			}
+		}
+	}
+
+	return ""
+}
+
+// validateAppRole checks a role given to a user in the app is one the app
+// could have discovered, and is when the discovered roles are known.
+//
+// The group filter and role value pattern only apply to role names, so an
+// ARN is only checked against the discovered roles.
+func validateAppRole(app *api.OktaApplication, discovered []string, role string) error {
+	settings := app.Settings.App
+
+	if !iamRoleValueArn.MatchString(role) {
+		if pattern := groupFilterRolePattern(settings.GroupFilter); pattern != "" {
+			syntax, err := regexp.Compile("^(?:" + pattern + ")$")
+			if err == nil && !syntax.MatchString(role) {
+				return fmt.Errorf("%q doesn't match the roles of app %s's group filter, %s", role, app.Label, pattern)
+			}
+		}
+
+		if strings.Contains(settings.RoleValuePattern, ":role/${role}") && !iamRoleName.MatchString(role) {
+			return fmt.Errorf("%q is not a valid IAM role name", role)
+		}
+	}
+
+	if len(discovered) == 0 {
+		return nil
+	}
+
+	for _, candidate := range discovered {
+		if candidate == role {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%q is not a role discovered in app %s, expected one of %s", role, app.Label, strings.Join(discovered, ", "))
+}
+
+type awsEnvironment struct {
+	Partition string
+	LoginURL  string
+}
+
+// The AWS environments an AWS app can federate with, by the environment
+// type Okta knows them as.
+var awsEnvironments = map[string]awsEnvironment{
+	"aws.amazon": {Partition: "aws", LoginURL: "https://console.aws.amazon.com/ec2/home"},
+	"aws.cn":     {Partition: "aws-cn", LoginURL: "https://console.amazonaws.cn/console/home"},
+	"aws.us-gov": {Partition: "aws-us-gov", LoginURL: "https://console.amazonaws-us-gov.com/console/home"},
+}
+
+var (
+	arnPartition          = regexp.MustCompile(`arn:aws(-cn|-us-gov)?:`)
+	samlProviderArn       = regexp.MustCompile(`^arn:([a-z-]+):iam::(\d{12}):saml-provider/([\w+=,.@-]{1,128})$`)
+	samlProviderArnPrefix = regexp.MustCompile(`arn:([a-z-]+):iam::(\d{12}):saml-provider/`)
+	defaultAwsRolePattern = api.NewAwsApplication("", "").Settings.App.RoleValuePattern
+)
+
+// applyAwsEnvironment points the app at the console and partition of the
+// AWS environment, rewriting the role value pattern's ARNs to match.
+func applyAwsEnvironment(app *api.OktaApplicationContents, environment string, rolePattern string) {
+	env, ok := awsEnvironments[environment]
+	if !ok {
+		return
+	}
+
+	app.Settings.App.AwsEnvironmentType = environment
+	app.Settings.App.LoginURL = env.LoginURL
+	app.Settings.App.RoleValuePattern = arnPartition.ReplaceAllString(rolePattern, "arn:"+env.Partition+":")
+}
+
+// splitArns returns the ARNs of a comma separated list, the way Okta takes
+// the identity providers of several accounts.
+func splitArns(arns string) []string {
+	result := []string{}
+	for _, arn := range strings.Split(arns, ",") {
+		if arn = strings.TrimSpace(arn); arn != "" {
+			result = append(result, arn)
+		}
+	}
+	return result
+}
+
+// validateIdentityProviderArn checks the ARN is of a SAML provider in the
+// partition of the AWS environment, and in the account the role value
+// pattern gives, so logins through it aren't rejected. The provider's name
+// isn't checked, as the default pattern's OKTA is often renamed.
+func validateIdentityProviderArn(arn string, environment string, rolePattern string) error {
+	parts := samlProviderArn.FindStringSubmatch(arn)
+	if parts == nil {
+		return fmt.Errorf("%q is not the ARN of an IAM SAML provider, such as arn:aws:iam::123456789012:saml-provider/OKTA", arn)
+	}
+
+	partition, account := parts[1], parts[2]
+	if env, ok := awsEnvironments[environment]; ok && env.Partition != partition {
+		return fmt.Errorf("%q is in partition %s, but the app's environment %s is in %s", arn, partition, environment, env.Partition)
+	}
+
+	for _, value := range strings.Split(rolePattern, ",") {
+		expected := samlProviderArnPrefix.FindStringSubmatch(strings.Replace(value, "${accountid}", account, -1))
+		if expected == nil {
+			continue
+		}
+
+		if expected[1] != partition || expected[2] != account {
+			return fmt.Errorf("%q isn't in the partition and account the role value pattern gives, %s", arn, strings.TrimSuffix(expected[0], "saml-provider/"))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package okta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+)
+
+func TestGroupFilterRolePattern(t *testing.T) {
+	cases := map[string]string{
+		`aws_(?{{accountid}}\d+)_(?{{role}}[a-zA-Z0-9+=,.@\-_]+)`: `[a-zA-Z0-9+=,.@\-_]+`,
+		`aws_(?{{role}}(Admin|ReadOnly))`:                         `(Admin|ReadOnly)`,
+		`aws_(?{{role}}[\(\)a-z]+)_x`:                             `[\(\)a-z]+`,
+		`^aws_.*$`:                                                "",
+	}
+
+	for groupFilter, expected := range cases {
+		if pattern := groupFilterRolePattern(groupFilter); pattern != expected {
+			t.Fatalf("expected %q from %q, got %q", expected, groupFilter, pattern)
+		}
+	}
+}
+
+func TestValidateAppRole(t *testing.T) {
+	app := &api.OktaApplication{OktaApplicationContents: api.NewAwsApplication("ACME-AwsAccount", "")}
+
+	if err := validateAppRole(app, nil, "ReadOnly"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if err := validateAppRole(app, []string{"Admin", "ReadOnly"}, "ReadOnly"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	arn := "arn:aws:iam::123456789012:saml-provider/OKTA,arn:aws:iam::123456789012:role/ReadOnly"
+	if err := validateAppRole(app, []string{arn}, arn); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cases := map[string]string{
+		"Read Only":             `"Read Only" doesn't match the roles of app ACME-AwsAccount's group filter`,
+		strings.Repeat("a", 65): "is not a valid IAM role name",
+		"ReadOnyl":              `"ReadOnyl" is not a role discovered in app ACME-AwsAccount, expected one of Admin, ReadOnly`,
+	}
+
+	for role, expected := range cases {
+		err := validateAppRole(app, []string{"Admin", "ReadOnly"}, role)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error containing %s for %q, got %v", expected, role, err)
+		}
+	}
+}
+
+func TestValidateIdentityProviderArn(t *testing.T) {
+	valid := []string{
+		"arn:aws:iam::123456789012:saml-provider/OKTA",
+		"arn:aws:iam::123456789012:saml-provider/Okta",
+		"arn:aws:iam::123456789012:saml-provider/OktaProd",
+	}
+
+	for _, arn := range valid {
+		if err := validateIdentityProviderArn(arn, "aws.amazon", defaultAwsRolePattern); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	cases := map[string]string{
+		"arn:aws:iam::123456789012:role/OKTA":             "is not the ARN of an IAM SAML provider",
+		"arn:aws:iam::12345:saml-provider/OKTA":           "is not the ARN of an IAM SAML provider",
+		"arn:aws-cn:iam::123456789012:saml-provider/OKTA": "is in partition aws-cn, but the app's environment aws.amazon is in aws",
+	}
+
+	for arn, expected := range cases {
+		err := validateIdentityProviderArn(arn, "aws.amazon", defaultAwsRolePattern)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error containing %s for %q, got %v", expected, arn, err)
+		}
+	}
+
+	pinned := "arn:aws:iam::111111111111:saml-provider/OKTA,arn:aws:iam::111111111111:role/${role}"
+	err := validateIdentityProviderArn("arn:aws:iam::222222222222:saml-provider/OKTA", "aws.amazon", pinned)
+	if err == nil || !strings.Contains(err.Error(), "isn't in the partition and account the role value pattern gives, arn:aws:iam::111111111111:") {
+		t.Fatalf("expected an error for an account the role value pattern doesn't give, got %v", err)
+	}
+
+	if err := validateIdentityProviderArn("arn:aws:iam::111111111111:saml-provider/OktaProd", "aws.amazon", pinned); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
@@ -4,19 +4,41 @@ import (
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 )
 
-func NewClient(c *Config) (api.Okta, api.OktaWebClient) {
-	okta := api.Okta{
-		HostURL:      c.OktaURL,
-		APIKey:       c.APIKey,
-		RetryMaximum: c.RetryMaximum,
+// The scopes requested when authenticating with OAuth, unless configured.
+var DefaultScopes = []string{
+	"okta.apps.manage",
+	"okta.apps.read",
+	"okta.groups.read",
+	"okta.users.read",
+}
+
+func NewClient(c *Config) (*api.Okta, *api.OktaWebClient) {
+	okta := &api.Okta{
+		HostURL:     c.OktaURL,
+		APIKey:      c.APIKey,
+		OrgID:       c.OrgID,
+		RetryPolicy: c.RetryPolicy,
+
+		MaxConcurrentRequests:          c.MaxConcurrentRequests,
+		MaxConcurrentRequestsPerBucket: c.MaxConcurrentRequestsPerEndpoint,
 	}
 
-	web := api.OktaWebClient{
-		HostURL:  c.OktaURL,
-		AdminURL: c.OktaAdminUrl,
-		UserName: c.UserName,
-		Password: c.Password,
-		OrgID:    c.OrgID,
+	if c.ClientID != "" {
+		okta.OAuth = &api.OAuthCredentials{
+			ClientID:   c.ClientID,
+			PrivateKey: c.PrivateKey,
+			KeyID:      c.PrivateKeyID,
+			Scopes:     c.Scopes,
+		}
+	}
+
+	web := &api.OktaWebClient{
+		HostURL:     c.OktaURL,
+		AdminURL:    c.OktaAdminUrl,
+		UserName:    c.UserName,
+		Password:    c.Password,
+		OrgID:       c.OrgID,
+		RetryPolicy: c.RetryPolicy,
 	}
 
 	return okta, web
@@ -26,10 +48,18 @@ type Config struct {
 	OktaURL      string
 	OktaAdminUrl string
 	APIKey       string
+	ClientID     string
+	PrivateKey   string
+	PrivateKeyID string
+	Scopes       []string
 	UserName     string
 	Password     string
 	OrgID        string
-	RetryMaximum int
-	Okta         api.Okta
-	Web          api.OktaWebClient
+	RetryPolicy  api.RetryPolicy
+
+	MaxConcurrentRequests            int
+	MaxConcurrentRequestsPerEndpoint int
+
+	Okta api.OktaAPI
+	Web  api.AdminWebAPI
 }
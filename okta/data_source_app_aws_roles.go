@@ -0,0 +1,130 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func dataSourceAppAwsRoles() *schema.Resource {
+	return &schema.Resource{
+		Read: withTimeout(schema.TimeoutRead, dataSourceAppAwsRolesRead),
+
+		Schema: map[string]*schema.Schema{
+			"application_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The unique identifier of the application",
+			},
+			"roles": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "The roles discovered in the AWS accounts, which users can be given",
+			},
+			"assignments": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The users holding each role",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"role": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"user_ids": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+						"user_names": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceAppAwsRolesRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
+	client := config.Okta
+
+	applicationID := d.Get("application_id").(string)
+
+	roles, err := client.GetAppRoles(ctx, applicationID)
+	if err != nil {
+		return err
+	}
+
+	if roles == nil {
+		return fmt.Errorf("Could not find the application: %s", applicationID)
+	}
+
+	members, err := client.ListAppMembers(ctx, applicationID)
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ID < members[j].ID
+	})
+
+	holders := map[string][]int{}
+	for i, member := range members {
+		held := member.Profile.SamlRoles
+		if len(held) == 0 && member.Profile.Role != "" {
+			held = []string{member.Profile.Role}
+		}
+
+		for _, role := range held {
+			holders[role] = append(holders[role], i)
+		}
+	}
+
+	// Roles can still be held after they are no longer discovered in the
+	// accounts, those are listed too so they show up in an audit.
+	available := map[string]bool{}
+	for _, role := range roles {
+		available[role] = true
+	}
+
+	assigned := append([]string{}, roles...)
+	for role := range holders {
+		if !available[role] {
+			log.Printf("[WARN] Role %s in app %s is held by users but no longer discovered", role, applicationID)
+			assigned = append(assigned, role)
+		}
+	}
+	sort.Strings(assigned)
+
+	assignments := []map[string]interface{}{}
+	for _, role := range assigned {
+		userIDs := []string{}
+		userNames := []string{}
+		for _, i := range holders[role] {
+			userIDs = append(userIDs, members[i].ID)
+			userNames = append(userNames, members[i].Credentials.UserName)
+		}
+
+		assignments = append(assignments, map[string]interface{}{
+			"role":       role,
+			"user_ids":   userIDs,
+			"user_names": userNames,
+		})
+	}
+
+	d.SetId(applicationID)
+	d.Set("roles", roles)
+	if err := d.Set("assignments", assignments); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package okta
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestDataSourceAppAwsRoles_read(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := dataSourceAppAwsRoles()
+	ctx := context.Background()
+
+	app, _ := okta.CreateAwsApplication(ctx, "ACME-AwsAccount", "")
+	okta.Roles[app.ID] = []string{"Admin", "Developer", "ReadOnly"}
+
+	jane := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+	john := okta.AddUser("jsmith@example.com", "jsmith@example.com")
+	okta.AddAppMember(ctx, app.ID, jane, "Developer", []string{"Developer", "ReadOnly"})
+	okta.AddAppMember(ctx, app.ID, john, "Retired", []string{"Retired"})
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": app.ID,
+	})
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(d.Get("roles"), []interface{}{"Admin", "Developer", "ReadOnly"}) {
+		t.Fatalf("unexpected roles %v", d.Get("roles"))
+	}
+
+	expected := map[string][]interface{}{
+		"Admin":     {},
+		"Developer": {"jdoe@example.com"},
+		"ReadOnly":  {"jdoe@example.com"},
+		"Retired":   {"jsmith@example.com"},
+	}
+
+	assignments := d.Get("assignments").([]interface{})
+	if len(assignments) != len(expected) {
+		t.Fatalf("unexpected assignments %v", assignments)
+	}
+
+	for _, raw := range assignments {
+		assignment := raw.(map[string]interface{})
+		names := assignment["user_names"].([]interface{})
+		if !reflect.DeepEqual(names, expected[assignment["role"].(string)]) {
+			t.Fatalf("unexpected users %v for role %s", names, assignment["role"])
+		}
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": "0oamissing",
+	})
+
+	if err := r.Read(d, config); err == nil {
+		t.Fatalf("expected an error for a missing application")
+	}
+}
@@ -1,60 +1,162 @@
 package okta
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"github.com/hashicorp/terraform/helper/schema"
 )
 
 func dataSourceAppSaml() *schema.Resource {
 	return &schema.Resource{
-		Read: dataSourceAppSamlRead,
+		Read: withTimeout(schema.TimeoutRead, dataSourceAppSamlRead),
 
 		Schema: map[string]*schema.Schema{
 			"application_id": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Description:   "The unique identifier of the application",
+				ConflictsWith: []string{"label"},
+			},
+			"label": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				Description:   "The label of the application, to look it up by instead of its ID",
+				ConflictsWith: []string{"application_id"},
+			},
+			"key_id": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
-				Description: "The unique identifier of the application",
-				ForceNew:    true,
+				Optional:    true,
+				Computed:    true,
+				Description: "The ID of the signing key to get the metadata for, defaults to the key the app signs with",
 			},
 			"saml_metadata_document": &schema.Schema{
 				Type:        schema.TypeString,
 				Computed:    true,
 				Description: "The SAML metadata",
 			},
+			"entity_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The entity ID of Okta as the identity provider",
+			},
+			"sso_redirect_url": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The single sign on URL for the HTTP-Redirect binding",
+			},
+			"sso_post_url": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The single sign on URL for the HTTP-POST binding",
+			},
+			"slo_url": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The single logout URL, when the app has single logout enabled",
+			},
+			"certificate": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The PEM encoded signing certificate",
+			},
+			"fingerprint_sha1": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"fingerprint_sha256": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"not_before": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the signing certificate becomes valid, in RFC 3339 format",
+			},
+			"not_after": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the signing certificate expires, in RFC 3339 format",
+			},
 		},
 	}
 }
 
-func dataSourceAppSamlRead(d *schema.ResourceData, meta interface{}) error {
-	config := meta.(Config)
+func dataSourceAppSamlRead(ctx context.Context, d *schema.ResourceData, meta interface{}) error {
+	config := meta.(*Config)
 	client := config.Okta
 
 	applicationID := d.Get("application_id").(string)
+	label := d.Get("label").(string)
 
-	log.Printf("[DEBUG] account: (AppID: %q)", applicationID)
-	app, err := client.GetApplication(applicationID)
+	var app *api.OktaApplication
+	var err error
+	lookup := applicationID
+	switch {
+	case applicationID != "":
+		log.Printf("[DEBUG] account: (AppID: %q)", applicationID)
+		app, err = client.GetApplication(ctx, applicationID)
+	case label != "":
+		log.Printf("[DEBUG] account: (Label: %q)", label)
+		lookup = fmt.Sprintf("labelled %q", label)
+		app, err = client.FindApplicationByLabel(ctx, label)
+	default:
+		return fmt.Errorf("One of application_id or label must be set")
+	}
 	if err != nil {
 		return err
 	}
 
 	if app == nil {
-		return fmt.Errorf("Could not find the application: %s", applicationID)
+		return fmt.Errorf("Could not find the application: %s", lookup)
 	}
 
-	log.Printf("[DEBUG] saml: (AppID: %q, KeyID: %q)", app.ID, app.Credentials.Signing.KeyID)
-	saml, err := client.GetSAMLMetadata(app.ID, app.Credentials.Signing.KeyID)
+	keyID := d.Get("key_id").(string)
+	if keyID == "" {
+		keyID = app.SigningKeyID()
+	}
+
+	log.Printf("[DEBUG] saml: (AppID: %q, KeyID: %q)", app.ID, keyID)
+	saml, err := client.GetSAMLMetadata(ctx, app.ID, keyID)
 	if err != nil {
 		return err
 	}
 
 	if saml == "" {
-		return fmt.Errorf("Metadata returned is invalid: %s", applicationID)
+		return fmt.Errorf("Metadata returned is invalid: %s", app.ID)
+	}
+
+	metadata, err := api.ParseSAMLMetadata(saml)
+	if err != nil {
+		return err
 	}
 
 	d.SetId(app.ID)
+	d.Set("application_id", app.ID)
+	d.Set("label", app.Label)
+	d.Set("key_id", keyID)
 	d.Set("saml_metadata_document", saml)
+	d.Set("entity_id", metadata.EntityID)
+	d.Set("sso_redirect_url", metadata.SSORedirectURL)
+	d.Set("sso_post_url", metadata.SSOPostURL)
+	d.Set("slo_url", metadata.SLOURL)
+	d.Set("certificate", metadata.Certificate)
+	d.Set("fingerprint_sha1", metadata.FingerprintSHA1)
+	d.Set("fingerprint_sha256", metadata.FingerprintSHA256)
+	d.Set("not_before", formatTime(metadata.NotBefore))
+	d.Set("not_after", formatTime(metadata.NotAfter))
 
 	return nil
 }
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,85 @@
+package okta
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestDataSourceAppSaml_read(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := dataSourceAppSaml()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": app.ID,
+	})
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Get("saml_metadata_document").(string) != okta.Metadata[app.ID] {
+		t.Fatalf("expected the SAML metadata, got %q", d.Get("saml_metadata_document"))
+	}
+
+	if d.Get("entity_id").(string) != "http://www.okta.com/"+app.ID || d.Get("sso_post_url").(string) == "" {
+		t.Fatalf("expected the entity ID and endpoints to be parsed, got %q and %q", d.Get("entity_id"), d.Get("sso_post_url"))
+	}
+
+	if !strings.HasPrefix(d.Get("certificate").(string), "-----BEGIN CERTIFICATE-----") || d.Get("fingerprint_sha256").(string) == "" {
+		t.Fatalf("expected the signing certificate to be parsed")
+	}
+
+	if d.Get("not_after").(string) != "2030-01-01T00:00:00Z" {
+		t.Fatalf("unexpected certificate expiry %q", d.Get("not_after"))
+	}
+
+	if d.Get("key_id").(string) != app.SigningKeyID() {
+		t.Fatalf("expected the app's signing key, got %q", d.Get("key_id"))
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": "0oamissing",
+	})
+
+	if err := r.Read(d, config); err == nil {
+		t.Fatalf("expected an error for a missing application")
+	}
+}
+
+func TestDataSourceAppSaml_readByLabelAndKey(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := dataSourceAppSaml()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	okta.Metadata[app.ID+"/kid-next"] = strings.Replace(okta.Metadata[app.ID], "http://www.okta.com/", "http://www.okta.com/next/", 1)
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"label":  "ACME-AwsAccount",
+		"key_id": "kid-next",
+	})
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != app.ID || d.Get("application_id").(string) != app.ID {
+		t.Fatalf("expected the app to be found by label, got %q", d.Id())
+	}
+
+	if d.Get("entity_id").(string) != "http://www.okta.com/next/"+app.ID {
+		t.Fatalf("expected the metadata for the selected key, got %q", d.Get("entity_id"))
+	}
+
+	d = schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"label": "ACME-Missing",
+	})
+
+	if err := r.Read(d, config); err == nil {
+		t.Fatalf("expected an error for a missing application")
+	}
+}
@@ -0,0 +1,395 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// The resources of each type of policy, and of their rules, are managed the
+// same way through the policies API. They only differ in the type they are
+// created with and the conditions, settings and actions they expand from the
+// configuration and flatten back into it.
+
+type policyExpandFunc func(d *schema.ResourceData, policy *api.OktaPolicy)
+
+type policyRuleExpandFunc func(d *schema.ResourceData, rule *api.OktaPolicyRule)
+
+// policyResource returns the resource for policies of the type, with the
+// attributes every policy has added to its schema. Types without conditions
+// or settings of their own have no expand or flatten.
+func policyResource(policyType string, s map[string]*schema.Schema, expand policyExpandFunc, flatten policyExpandFunc) *schema.Resource {
+	s["name"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+	}
+	s["description"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+	}
+	s["priority"] = &schema.Schema{
+		Type:         schema.TypeInt,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.IntAtLeast(1),
+		Description:  "The order the policy is evaluated in among those of its type, from 1. Policies at or after it are moved down, it is added before the default policy when not set",
+	}
+	s["status"] = statusSchema()
+
+	read := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		policy, err := client.GetPolicy(ctx, d.Id())
+		if err != nil {
+			return err
+		}
+
+		if policy == nil {
+			log.Printf("[WARN] Okta policy not found, removing from state: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		if policy.Type != policyType {
+			return fmt.Errorf("Policy %s is a %s policy, not %s", d.Id(), policy.Type, policyType)
+		}
+
+		d.Set("name", policy.Name)
+		d.Set("description", policy.Description)
+		d.Set("priority", policy.Priority)
+		d.Set("status", policy.Status)
+		if flatten != nil {
+			flatten(d, policy)
+		}
+
+		return nil
+	}
+
+	build := func(d *schema.ResourceData) api.OktaPolicy {
+		policy := api.OktaPolicy{
+			ID:          d.Id(),
+			Type:        policyType,
+			Name:        d.Get("name").(string),
+			Description: d.Get("description").(string),
+			Priority:    d.Get("priority").(int),
+		}
+		if expand != nil {
+			expand(d, &policy)
+		}
+		return policy
+	}
+
+	create := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		policy, err := client.CreatePolicy(ctx, build(d))
+		if err != nil {
+			return err
+		}
+
+		d.SetId(policy.ID)
+
+		if d.Get("status").(string) == "INACTIVE" {
+			if err := client.SetPolicyActive(ctx, policy.ID, false); err != nil {
+				return err
+			}
+		}
+
+		return read(ctx, d, m)
+	}
+
+	update := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		if _, err := client.UpdatePolicy(ctx, build(d)); err != nil {
+			return err
+		}
+
+		if d.HasChange("status") {
+			if err := client.SetPolicyActive(ctx, d.Id(), d.Get("status").(string) == "ACTIVE"); err != nil {
+				return err
+			}
+		}
+
+		return read(ctx, d, m)
+	}
+
+	remove := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		return client.DeletePolicy(ctx, d.Id())
+	}
+
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, create),
+		Read:   withTimeout(schema.TimeoutRead, read),
+		Update: withTimeout(schema.TimeoutUpdate, update),
+		Delete: withTimeout(schema.TimeoutDelete, remove),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: s,
+	}
+}
+
+// groupsIncludedSchema is the groups a sign-on, password or factor
+// enrollment policy applies to.
+func groupsIncludedSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeSet,
+		Required:    true,
+		MinItems:    1,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Set:         schema.HashString,
+		Description: "The IDs of the groups the policy applies to",
+	}
+}
+
+func policyPeople(d *schema.ResourceData) *api.OktaPolicyPeopleCondition {
+	return &api.OktaPolicyPeopleCondition{
+		Groups: api.OktaInclude{Include: stringSet(d, "groups_included")},
+	}
+}
+
+func setPolicyPeople(d *schema.ResourceData, conditions *api.OktaPolicyConditions) {
+	groups := []string{}
+	if conditions != nil && conditions.People != nil {
+		groups = conditions.People.Groups.Include
+	}
+	d.Set("groups_included", groups)
+}
+
+// policyRuleResource returns the resource for rules of the type, with the
+// attributes every rule has added to its schema. Rules can exclude users and
+// be limited to where users sign in from.
+func policyRuleResource(ruleType string, s map[string]*schema.Schema, expand policyRuleExpandFunc, flatten policyRuleExpandFunc, customizeDiff schema.CustomizeDiffFunc) *schema.Resource {
+	s["policy_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+		ForceNew: true,
+	}
+	s["name"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+	}
+	s["priority"] = &schema.Schema{
+		Type:         schema.TypeInt,
+		Optional:     true,
+		Computed:     true,
+		ValidateFunc: validation.IntAtLeast(1),
+		Description:  "The order the rule is evaluated in within the policy, from 1. It is added before the default rule when not set",
+	}
+	s["status"] = statusSchema()
+	s["users_excluded"] = &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Set:         schema.HashString,
+		Description: "The IDs of the users the rule doesn't apply to",
+	}
+	s["network_connection"] = &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      "ANYWHERE",
+		ValidateFunc: validation.StringInSlice([]string{"ANYWHERE", "ZONE"}, false),
+		Description:  "ZONE to only apply the rule to users signing in from, or not from, the network zones",
+	}
+	s["network_includes"] = &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Set:         schema.HashString,
+		Description: "The IDs of the network zones the rule applies to users signing in from",
+	}
+	s["network_excludes"] = &schema.Schema{
+		Type:        schema.TypeSet,
+		Optional:    true,
+		Elem:        &schema.Schema{Type: schema.TypeString},
+		Set:         schema.HashString,
+		Description: "The IDs of the network zones the rule doesn't apply to users signing in from",
+	}
+
+	read := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		rule, err := client.GetPolicyRule(ctx, d.Get("policy_id").(string), d.Id())
+		if err != nil {
+			return err
+		}
+
+		if rule == nil {
+			log.Printf("[WARN] Okta policy rule not found, removing from state: %s", d.Id())
+			d.SetId("")
+			return nil
+		}
+
+		if rule.Type != ruleType {
+			return fmt.Errorf("Policy rule %s is a %s rule, not %s", d.Id(), rule.Type, ruleType)
+		}
+
+		users := []string{}
+		if people := rule.Conditions.People; people != nil && people.Users != nil {
+			users = people.Users.Exclude
+		}
+
+		network := api.OktaNetworkCondition{Connection: "ANYWHERE"}
+		if rule.Conditions.Network != nil {
+			network = *rule.Conditions.Network
+		}
+
+		d.Set("name", rule.Name)
+		d.Set("priority", rule.Priority)
+		d.Set("status", rule.Status)
+		d.Set("users_excluded", users)
+		d.Set("network_connection", network.Connection)
+		d.Set("network_includes", network.Include)
+		d.Set("network_excludes", network.Exclude)
+		flatten(d, rule)
+
+		return nil
+	}
+
+	build := func(d *schema.ResourceData) api.OktaPolicyRule {
+		rule := api.OktaPolicyRule{
+			ID:       d.Id(),
+			Type:     ruleType,
+			Name:     d.Get("name").(string),
+			Priority: d.Get("priority").(int),
+			Conditions: api.OktaPolicyRuleConditions{
+				People: &api.OktaPolicyRulePeopleCondition{
+					Users: &api.OktaIncludeExclude{
+						Include: []string{},
+						Exclude: stringSet(d, "users_excluded"),
+					},
+				},
+				Network: &api.OktaNetworkCondition{
+					Connection: d.Get("network_connection").(string),
+					Include:    stringSet(d, "network_includes"),
+					Exclude:    stringSet(d, "network_excludes"),
+				},
+			},
+		}
+		expand(d, &rule)
+		return rule
+	}
+
+	create := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+		policyID := d.Get("policy_id").(string)
+
+		rule, err := client.CreatePolicyRule(ctx, policyID, build(d))
+		if err != nil {
+			return err
+		}
+
+		d.SetId(rule.ID)
+
+		if d.Get("status").(string) == "INACTIVE" {
+			if err := client.SetPolicyRuleActive(ctx, policyID, rule.ID, false); err != nil {
+				return err
+			}
+		}
+
+		return read(ctx, d, m)
+	}
+
+	update := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+		policyID := d.Get("policy_id").(string)
+
+		if _, err := client.UpdatePolicyRule(ctx, policyID, build(d)); err != nil {
+			return err
+		}
+
+		if d.HasChange("status") {
+			if err := client.SetPolicyRuleActive(ctx, policyID, d.Id(), d.Get("status").(string) == "ACTIVE"); err != nil {
+				return err
+			}
+		}
+
+		return read(ctx, d, m)
+	}
+
+	remove := func(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+		config := m.(*Config)
+		client := config.Okta
+
+		return client.DeletePolicyRule(ctx, d.Get("policy_id").(string), d.Id())
+	}
+
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, create),
+		Read:   withTimeout(schema.TimeoutRead, read),
+		Update: withTimeout(schema.TimeoutUpdate, update),
+		Delete: withTimeout(schema.TimeoutDelete, remove),
+
+		CustomizeDiff: func(d *schema.ResourceDiff, m interface{}) error {
+			if err := policyRuleNetworkCustomizeDiff(d); err != nil {
+				return err
+			}
+			if customizeDiff != nil {
+				return customizeDiff(d, m)
+			}
+			return nil
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: s,
+	}
+}
+
+// policyRuleNetworkCustomizeDiff checks the network zones are only given
+// for a ZONE connection, which Okta needs either included or excluded zones
+// for but not both.
+func policyRuleNetworkCustomizeDiff(d *schema.ResourceDiff) error {
+	if !d.NewValueKnown("network_connection") || !d.NewValueKnown("network_includes") || !d.NewValueKnown("network_excludes") {
+		return nil
+	}
+
+	zone := d.Get("network_connection").(string) == "ZONE"
+	includes := d.Get("network_includes").(*schema.Set).Len() > 0
+	excludes := d.Get("network_excludes").(*schema.Set).Len() > 0
+
+	if !zone && (includes || excludes) {
+		return fmt.Errorf("network_includes and network_excludes can only be set when network_connection is ZONE")
+	}
+	if zone && !includes && !excludes {
+		return fmt.Errorf("One of network_includes or network_excludes must be set when network_connection is ZONE")
+	}
+	if includes && excludes {
+		return fmt.Errorf("Only one of network_includes or network_excludes can be set")
+	}
+
+	return nil
+}
+
+// accessSchema is whether a rule allows or denies something.
+func accessSchema(defaultAccess string, description string) *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      defaultAccess,
+		ValidateFunc: validation.StringInSlice([]string{"ALLOW", "DENY"}, false),
+		Description:  description,
+	}
+}
@@ -1,7 +1,13 @@
 package okta
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/hashicorp/terraform/terraform"
 )
 
@@ -16,45 +22,150 @@ func Provider() terraform.ResourceProvider {
 			},
 			"okta_admin_url": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OKTA_ADMIN_URL", nil),
-				Description: "This is the Okta Admin WebUI URL. It must be provided, but it can also be sourced from the `OKTA_ADMIN_URL` environment variable.",
+				Description: "This is the Okta Admin WebUI URL. It is only required to manage `okta_app_aws_provision`, but it can also be sourced from the `OKTA_ADMIN_URL` environment variable.",
 			},
 			"api_key": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OKTA_API_KEY", nil),
-				Description: "This is the Okta API token. It must be provided, but it can also be sourced from the `OKTA_API_KEY` environment variable.",
+				Description: "This is the Okta API token. It must be provided unless `client_id` is, but it can also be sourced from the `OKTA_API_KEY` environment variable.",
 				Sensitive:   true,
 			},
+			"client_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OKTA_CLIENT_ID", nil),
+				Description: "This is the client ID of an Okta service app to authenticate with OAuth instead of an API token. It can also be sourced from the `OKTA_CLIENT_ID` environment variable.",
+			},
+			"private_key": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OKTA_PRIVATE_KEY", nil),
+				Description: "This is the private key of the service app, as PEM or a JWK, used to sign the client assertion. It can also be sourced from the `OKTA_PRIVATE_KEY` environment variable.",
+				Sensitive:   true,
+			},
+			"private_key_id": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("OKTA_PRIVATE_KEY_ID", nil),
+				Description: "This is the ID of the service app key, sent as the `kid` of the client assertion. It can also be sourced from the `OKTA_PRIVATE_KEY_ID` environment variable.",
+			},
+			"scopes": &schema.Schema{
+				Type: schema.TypeSet,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+				Optional:    true,
+				Description: "These are the OAuth scopes requested for the service app. Defaults to the scopes needed to manage applications and read users and groups.",
+			},
 			"username": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OKTA_USERNAME", nil),
-				Description: "This is the username of a user that can log into the Admin WebUI. It must be provided, but it can also be sourced from the `OKTA_USERNAME` environment variable.",
+				Description: "This is the username of a user that can log into the Admin WebUI. It is only required to manage `okta_app_aws_provision`, but it can also be sourced from the `OKTA_USERNAME` environment variable.",
 				Sensitive:   true,
 			},
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OKTA_PASSWORD", nil),
-				Description: "This is the password of a user that can log into the Admin WebUI. It must be provided, but it can also be sourced from the `OKTA_PASSWORD` environment variable.",
+				Description: "This is the password of a user that can log into the Admin WebUI. It is only required to manage `okta_app_aws_provision`, but it can also be sourced from the `OKTA_PASSWORD` environment variable.",
 				Sensitive:   true,
 			},
 			"org_id": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				DefaultFunc: schema.EnvDefaultFunc("OKTA_ORG_ID", nil),
-				Description: "This is the Okta ID for the organization. It must be provided, but it can also be sourced from the `OKTA_ORG_ID` environment variable.",
+				Description: "This is the Okta ID for the organization. It isn't used by any resource, and is only kept so existing configurations still work.",
+				Deprecated:  "org_id isn't used by the provider and can be removed",
+			},
+			"max_retries": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      25,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of times a failed request to Okta is retried.",
+			},
+			"min_wait_seconds": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The minimum time to wait before retrying a request, in seconds.",
+			},
+			"max_wait_seconds": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      900,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The maximum time to wait before retrying a request, in seconds. The wait grows exponentially up to this limit.",
+			},
+			"retry_jitter": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Whether to randomise the wait between retries, so parallel requests don't retry in lockstep.",
+			},
+			"retryable_status_codes": &schema.Schema{
+				Type: schema.TypeList,
+				Elem: &schema.Schema{
+					Type:         schema.TypeInt,
+					ValidateFunc: validation.IntBetween(400, 599),
+				},
+				Optional:    true,
+				Description: "The HTTP status codes that cause a request to be retried. Defaults to 429 only.",
+			},
+			"max_concurrent_requests": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of requests in flight to Okta at once, across all resources. Defaults to no limit.",
+			},
+			"max_concurrent_requests_per_endpoint": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "The maximum number of requests in flight at once to a single Okta endpoint, which share a rate limit. Defaults to no limit.",
+			},
+			"retry_connection_errors": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether requests that fail because the connection was reset are retried.",
+			},
+			"retry_non_idempotent_requests": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Whether requests that aren't idempotent, such as creating an app, are retried on errors other than rate limiting. They may be applied twice.",
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"okta_app_aws":           resourceAppAws(),
-			"okta_app_aws_provision": resourceAppAwsProvision(),
-			"okta_user_attachment":   resourceAppUserAttachment(),
+			"okta_app_aws":                 resourceAppAws(),
+			"okta_app_aws_identity_center": resourceAppAwsIdentityCenter(),
+			"okta_app_aws_provision":       resourceAppAwsProvision(),
+			"okta_auth_server":             resourceAuthServer(),
+			"okta_auth_server_claim":       resourceAuthServerClaim(),
+			"okta_auth_server_policy":      resourceAuthServerPolicy(),
+			"okta_auth_server_policy_rule": resourceAuthServerPolicyRule(),
+			"okta_auth_server_scope":       resourceAuthServerScope(),
+			"okta_policy_app_signon":       resourcePolicyAppSignOn(),
+			"okta_policy_mfa_enroll":       resourcePolicyMfaEnroll(),
+			"okta_policy_password":         resourcePolicyPassword(),
+			"okta_policy_rule_app_signon":  resourcePolicyRuleAppSignOn(),
+			"okta_policy_rule_mfa_enroll":  resourcePolicyRuleMfaEnroll(),
+			"okta_policy_rule_password":    resourcePolicyRulePassword(),
+			"okta_policy_rule_signon":      resourcePolicyRuleSignOn(),
+			"okta_policy_signon":           resourcePolicySignOn(),
+			"okta_user_attachment":         resourceAppUserAttachment(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
-			"okta_app_saml": dataSourceAppSaml(),
+			"okta_app_saml":      dataSourceAppSaml(),
+			"okta_app_aws_roles": dataSourceAppAwsRoles(),
 		},
 		ConfigureFunc: configureProvider,
 	}
@@ -65,15 +176,57 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 		OktaURL:      d.Get("okta_url").(string),
 		OktaAdminUrl: d.Get("okta_admin_url").(string),
 		APIKey:       d.Get("api_key").(string),
+		ClientID:     d.Get("client_id").(string),
+		PrivateKey:   d.Get("private_key").(string),
+		PrivateKeyID: d.Get("private_key_id").(string),
+		Scopes:       DefaultScopes,
 		UserName:     d.Get("username").(string),
 		Password:     d.Get("password").(string),
 		OrgID:        d.Get("org_id").(string),
-		RetryMaximum: 25,
+
+		MaxConcurrentRequests:            d.Get("max_concurrent_requests").(int),
+		MaxConcurrentRequestsPerEndpoint: d.Get("max_concurrent_requests_per_endpoint").(int),
+
+		RetryPolicy: api.RetryPolicy{
+			MaxRetries:       d.Get("max_retries").(int),
+			MinWait:          time.Duration(d.Get("min_wait_seconds").(int)) * time.Second,
+			MaxWait:          time.Duration(d.Get("max_wait_seconds").(int)) * time.Second,
+			Jitter:           d.Get("retry_jitter").(bool),
+			StatusCodes:      []int{http.StatusTooManyRequests},
+			ConnectionErrors: d.Get("retry_connection_errors").(bool),
+			NonIdempotent:    d.Get("retry_non_idempotent_requests").(bool),
+		},
+	}
+
+	if codes := d.Get("retryable_status_codes").([]interface{}); len(codes) > 0 {
+		config.RetryPolicy.StatusCodes = make([]int, len(codes))
+		for i, code := range codes {
+			config.RetryPolicy.StatusCodes[i] = code.(int)
+		}
+	}
+
+	if scopes := d.Get("scopes").(*schema.Set).List(); len(scopes) > 0 {
+		config.Scopes = make([]string, len(scopes))
+		for i, scope := range scopes {
+			config.Scopes[i] = scope.(string)
+		}
+	}
+
+	if config.ClientID == "" && config.APIKey == "" {
+		return nil, fmt.Errorf("Either api_key, or client_id and private_key must be provided")
+	}
+
+	if config.ClientID != "" && config.PrivateKey == "" {
+		return nil, fmt.Errorf("private_key must be provided when authenticating with client_id")
+	}
+
+	if config.RetryPolicy.MaxWait < config.RetryPolicy.MinWait {
+		return nil, fmt.Errorf("max_wait_seconds must not be less than min_wait_seconds")
 	}
 
 	okta, web := NewClient(&config)
 	config.Okta = okta
 	config.Web = web
 
-	return config, nil
+	return &config, nil
 }
@@ -3,6 +3,8 @@ package okta
 import (
 	"testing"
 
+	"github.com/Brightspace/terraform-provider-okta/okta/api/fake"
+	"github.com/hashicorp/terraform/config"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/hashicorp/terraform/terraform"
 )
@@ -26,3 +28,28 @@ func TestProvider_impl(t *testing.T) {
 func testAccPreCheck(t *testing.T) {
 
 }
+
+// testFakeConfig returns a provider configuration backed by the in-memory
+// fake clients, for unit testing resources.
+func testFakeConfig() (*Config, *fake.Okta, *fake.Web) {
+	okta := fake.NewOkta()
+	web := fake.NewWeb(okta)
+
+	config := &Config{
+		OktaURL: "https://example.okta.com",
+		Okta:    okta,
+		Web:     web,
+	}
+
+	return config, okta, web
+}
+
+// testResourceConfig returns the resource configuration, for planning
+// changes with Diff and applying them with Apply.
+func testResourceConfig(t *testing.T, values map[string]interface{}) *terraform.ResourceConfig {
+	raw, err := config.NewRawConfig(values)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return terraform.NewResourceConfig(raw)
+}
@@ -1,26 +1,76 @@
 package okta
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"sort"
+	"strings"
 
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 )
 
 func resourceAppAws() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceAppAwsCreate,
-		Read:   resourceAppAwsRead,
-		Update: resourceAppAwsUpdate,
-		Delete: resourceAppAwsDelete,
+		Create: withTimeout(schema.TimeoutCreate, resourceAppAwsCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAppAwsRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAppAwsUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAppAwsDelete),
 
-		Schema: map[string]*schema.Schema{
+		CustomizeDiff: resourceAppAwsCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: appSettingsSchema(map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:     schema.TypeString,
 				Required: true,
 			},
 			"identity_provider_arn": &schema.Schema{
-				Type:     schema.TypeString,
-				Required: true,
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"identity_provider_arns"},
+				Description:   "The ARN of the IAM SAML provider, or of one in each account separated by commas",
+			},
+			"identity_provider_arns": &schema.Schema{
+				Type:          schema.TypeSet,
+				Optional:      true,
+				Computed:      true,
+				Elem:          &schema.Schema{Type: schema.TypeString},
+				Set:           schema.HashString,
+				ConflictsWith: []string{"identity_provider_arn"},
+				Description:   "The ARNs of the IAM SAML provider in each account the app federates with",
+			},
+			"join_all_roles": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Computed:    true,
+				Description: "Merge the roles of every account, so users are given roles by name rather than per account",
+			},
+			"account_roles": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "The roles discovered in each account. Roles not told apart by account, as when they are joined, have an empty account_id",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"account_id": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"roles": &schema.Schema{
+							Type:     schema.TypeList,
+							Computed: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
 			},
 			"application_id": &schema.Schema{
 				Type:     schema.TypeString,
@@ -35,8 +85,11 @@ func resourceAppAws() *schema.Resource {
 				Computed: true,
 			},
 			"aws_environment_type": &schema.Schema{
-				Type:     schema.TypeString,
-				Computed: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringInSlice([]string{"aws.amazon", "aws.cn", "aws.us-gov"}, false),
+				Description:  "The AWS environment, aws.amazon by default, or aws.cn or aws.us-gov",
 			},
 			"login_url": &schema.Schema{
 				Type:     schema.TypeString,
@@ -54,32 +107,121 @@ func resourceAppAws() *schema.Resource {
 				Type:     schema.TypeString,
 				Computed: true,
 			},
-		},
+		}),
+	}
+}
+
+// The identity provider ARNs are checked against the AWS environment and the
+// role value pattern, as Okta accepts ones that AWS then rejects logins for.
+func resourceAppAwsCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("aws_environment_type") {
+		return nil
+	}
+
+	// On create, whichever of the ARN attributes isn't configured is unknown
+	// until it is read back. On update it still holds the ARNs read back
+	// before, so only the one being changed is checked when the other isn't.
+	checkArn := d.NewValueKnown("identity_provider_arn")
+	checkArns := d.NewValueKnown("identity_provider_arns")
+	if d.HasChange("identity_provider_arn") != d.HasChange("identity_provider_arns") {
+		checkArn = checkArn && d.HasChange("identity_provider_arn")
+		checkArns = checkArns && d.HasChange("identity_provider_arns")
+	}
+
+	arns := []string{}
+	if checkArn {
+		arns = append(arns, splitArns(d.Get("identity_provider_arn").(string))...)
+	}
+	if checkArns {
+		for _, arn := range d.Get("identity_provider_arns").(*schema.Set).List() {
+			arns = append(arns, arn.(string))
+		}
+	}
+
+	environment := d.Get("aws_environment_type").(string)
+	if environment == "" {
+		environment = "aws.amazon"
+	}
+
+	rolePattern := d.Get("role_value_pattern").(string)
+	if rolePattern == "" {
+		rolePattern = defaultAwsRolePattern
+	}
+	if d.HasChange("aws_environment_type") {
+		app := api.OktaApplicationContents{}
+		applyAwsEnvironment(&app, environment, rolePattern)
+		rolePattern = app.Settings.App.RoleValuePattern
+	}
+
+	problems := []string{}
+	checked := map[string]bool{}
+	for _, arn := range arns {
+		if checked[arn] {
+			continue
+		}
+		checked[arn] = true
+
+		if err := validateIdentityProviderArn(arn, environment, rolePattern); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid identity provider ARNs:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
+
+// awsIdentityProviderArn returns the identity provider ARNs to give Okta,
+// comma separated, from whichever attribute sets them.
+func awsIdentityProviderArn(d *schema.ResourceData) string {
+	arns := d.Get("identity_provider_arns").(*schema.Set)
+	if arns.Len() == 0 || !d.HasChange("identity_provider_arns") {
+		return d.Get("identity_provider_arn").(string)
+	}
+
+	sorted := []string{}
+	for _, arn := range arns.List() {
+		sorted = append(sorted, arn.(string))
 	}
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
 }
 
-func resourceAppAwsCreate(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppAwsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 
 	name := d.Get("name").(string)
-	identityArn := d.Get("identity_provider_arn").(string)
+	identityArn := awsIdentityProviderArn(d)
+	if identityArn == "" {
+		return fmt.Errorf("One of identity_provider_arn or identity_provider_arns must be set")
+	}
+
+	application := api.NewAwsApplication(name, identityArn)
+	if environment := d.Get("aws_environment_type").(string); environment != "" {
+		applyAwsEnvironment(&application, environment, application.Settings.App.RoleValuePattern)
+	}
+	application.Settings.App.JoinAllRoles = api.Bool(d.Get("join_all_roles").(bool))
+	applyAppSettings(d, &application)
 
-	application, err := client.CreateAwsApplication(name, identityArn)
+	created, err := client.CreateApplication(ctx, application)
 	if err != nil {
 		return err
 	}
 
-	d.SetId(application.ID)
-	return resourceAppAwsRead(d, m)
+	d.SetId(created.ID)
+	return resourceAppAwsRead(ctx, d, m)
 }
 
-func resourceAppAwsRead(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppAwsRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 	appID := d.Id()
 
-	app, err := client.GetApplication(appID)
+	app, err := client.GetApplication(ctx, appID)
 	if err != nil {
 		return err
 	}
@@ -90,7 +232,7 @@ func resourceAppAwsRead(d *schema.ResourceData, m interface{}) error {
 		return nil
 	}
 
-	saml, err := client.GetSAMLMetadata(app.ID, app.Credentials.Signing.KeyID)
+	saml, err := client.GetSAMLMetadata(ctx, app.ID, app.SigningKeyID())
 	if err != nil {
 		return err
 	}
@@ -102,40 +244,77 @@ func resourceAppAwsRead(d *schema.ResourceData, m interface{}) error {
 	d.Set("aws_environment_type", app.Settings.App.AwsEnvironmentType)
 	d.Set("login_url", app.Settings.App.LoginURL)
 	d.Set("identity_provider_arn", app.Settings.App.IdentityProviderArn)
+	d.Set("identity_provider_arns", splitArns(app.Settings.App.IdentityProviderArn))
+	d.Set("join_all_roles", api.BoolValue(app.Settings.App.JoinAllRoles))
 	d.Set("session_duration", app.Settings.App.SessionDuration)
 	d.Set("role_value_pattern", app.Settings.App.RoleValuePattern)
 	d.Set("saml_metadata_document", saml)
+	setAppSettings(d, app)
+
+	appSchema, err := client.GetAppUserSchema(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	accountRoles := []map[string]interface{}{}
+	if appSchema != nil {
+		roles := appSchema.AccountRoles()
+
+		accounts := make([]string, 0, len(roles))
+		for account := range roles {
+			accounts = append(accounts, account)
+		}
+		sort.Strings(accounts)
+
+		for _, account := range accounts {
+			accountRoles = append(accountRoles, map[string]interface{}{
+				"account_id": account,
+				"roles":      roles[account],
+			})
+		}
+	}
+
+	if err := d.Set("account_roles", accountRoles); err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func resourceAppAwsUpdate(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppAwsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 
 	name := d.Get("name").(string)
-	identityArn := d.Get("identity_provider_arn").(string)
+	identityArn := awsIdentityProviderArn(d)
+
+	application := api.NewAwsApplicationUpdate(d.Id(), name, identityArn)
+	if environment := d.Get("aws_environment_type").(string); environment != "" {
+		applyAwsEnvironment(&application, environment, d.Get("role_value_pattern").(string))
+	}
+	application.Settings.App.JoinAllRoles = api.Bool(d.Get("join_all_roles").(bool))
+	applyAppSettings(d, &application)
 
-	app, err := client.UpdateAwsApplication(d.Id(), name, identityArn)
+	app, err := client.UpdateApplication(ctx, application)
 	if err != nil {
 		return err
 	}
 
 	d.SetId(app.ID)
-	return resourceAppAwsRead(d, m)
+	return resourceAppAwsRead(ctx, d, m)
 }
 
-func resourceAppAwsDelete(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppAwsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 	appID := d.Id()
 
-	err := client.DeactivateApplication(appID)
+	err := client.DeactivateApplication(ctx, appID)
 	if err != nil {
 		return err
 	}
 
-	err = client.DeleteApplication(appID)
+	err = client.DeleteApplication(ctx, appID)
 	if err != nil {
 		return err
 	}
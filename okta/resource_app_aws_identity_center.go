@@ -0,0 +1,416 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+var awsIdentityCenterSecrets = []string{"scim_endpoint_url", "scim_access_token"}
+
+func resourceAppAwsIdentityCenter() *schema.Resource {
+	suppressSecrets := suppressMatchingSecrets("scim_credentials_salt", "scim_credentials_hash", awsIdentityCenterSecrets...)
+
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAppAwsIdentityCenterCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAppAwsIdentityCenterRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAppAwsIdentityCenterUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAppAwsIdentityCenterDelete),
+
+		CustomizeDiff: resourceAppAwsIdentityCenterCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: appSettingsSchema(map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"identity_center_metadata": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"acs_url", "issuer_url"},
+				Description:   "The SAML metadata document of the IAM Identity Center instance, which the ACS URL and issuer are read from",
+			},
+			"acs_url": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"identity_center_metadata"},
+				ValidateFunc:  validateHTTPSURL,
+				Description:   "The IAM Identity Center assertion consumer service URL",
+			},
+			"issuer_url": &schema.Schema{
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"identity_center_metadata"},
+				ValidateFunc:  validateHTTPSURL,
+				Description:   "The IAM Identity Center issuer URL, the audience of the assertions",
+			},
+			"scim_endpoint_url": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				ValidateFunc:     validateHTTPSURL,
+				DiffSuppressFunc: suppressSecrets,
+				Description:      "The SCIM endpoint of the IAM Identity Center instance, users are provisioned to it when set",
+			},
+			"scim_access_token": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressSecrets,
+				Description:      "The access token Okta authenticates to the SCIM endpoint with",
+			},
+			"push_new_users": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Create users in IAM Identity Center when they are assigned to the app",
+			},
+			"push_profile_updates": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Update users in IAM Identity Center when their Okta profile changes",
+			},
+			"deactivate_users": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Deactivate users in IAM Identity Center when they are unassigned from the app",
+			},
+			"scim_credentials_salt": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"scim_credentials_hash": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Salted hash of the SCIM endpoint and token, used to detect a change of either",
+			},
+			"scim_status": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "ENABLED when users are provisioned to IAM Identity Center",
+			},
+			"application_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"label": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"sign_on_mode": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"saml_metadata_document": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		}),
+	}
+}
+
+// validateHTTPSURL doesn't include the value in its errors, as it checks
+// sensitive URLs too.
+func validateHTTPSURL(v interface{}, k string) (ws []string, errors []error) {
+	parsed, err := url.Parse(v.(string))
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		errors = append(errors, fmt.Errorf("%s must be an https URL", k))
+	}
+	return
+}
+
+// The ACS URL and issuer are read from the metadata at plan time, so a
+// change to either is shown. A change to the SCIM secrets is found by
+// comparing their hash, as they are never kept in state.
+func resourceAppAwsIdentityCenterCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if d.NewValueKnown("identity_center_metadata") {
+		if document := d.Get("identity_center_metadata").(string); document != "" {
+			provider, err := api.ParseSAMLServiceProviderMetadata(document)
+			if err != nil {
+				return err
+			}
+
+			if provider.ACSURL != d.Get("acs_url").(string) {
+				if err := d.SetNew("acs_url", provider.ACSURL); err != nil {
+					return err
+				}
+			}
+			if provider.EntityID != d.Get("issuer_url").(string) {
+				if err := d.SetNew("issuer_url", provider.EntityID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for _, key := range awsIdentityCenterSecrets {
+		if !d.NewValueKnown(key) {
+			if d.Id() == "" {
+				return nil
+			}
+			return d.SetNewComputed("scim_credentials_hash")
+		}
+	}
+
+	values := secretValues(d, awsIdentityCenterSecrets...)
+	if (values[0] == "") != (values[1] == "") {
+		return fmt.Errorf("Both scim_endpoint_url and scim_access_token must be provided")
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	hash := hashSecret(d.Get("scim_credentials_salt").(string), values...)
+	if hash == d.Get("scim_credentials_hash").(string) {
+		return nil
+	}
+
+	log.Printf("[INFO] SCIM credentials for application %s have changed", d.Id())
+	return d.SetNew("scim_credentials_hash", hash)
+}
+
+// awsIdentityCenterSAML returns the ACS URL and issuer of the Identity
+// Center instance, from its metadata when it is given.
+func awsIdentityCenterSAML(d *schema.ResourceData) (string, string, error) {
+	if document := d.Get("identity_center_metadata").(string); document != "" {
+		provider, err := api.ParseSAMLServiceProviderMetadata(document)
+		if err != nil {
+			return "", "", err
+		}
+		return provider.ACSURL, provider.EntityID, nil
+	}
+
+	acsURL := d.Get("acs_url").(string)
+	issuerURL := d.Get("issuer_url").(string)
+	if acsURL == "" || issuerURL == "" {
+		return "", "", fmt.Errorf("Either identity_center_metadata, or acs_url and issuer_url must be provided")
+	}
+
+	return acsURL, issuerURL, nil
+}
+
+func awsIdentityCenterCapabilities(d *schema.ResourceData) api.OktaProvisioningCapabilities {
+	return api.OktaProvisioningCapabilities{
+		Create: api.OktaProvisioningCreate{
+			LifecycleCreate: api.CapabilityStatus(d.Get("push_new_users").(bool)),
+		},
+		Update: api.OktaProvisioningUpdate{
+			LifecycleDeactivate: api.CapabilityStatus(d.Get("deactivate_users").(bool)),
+			Profile:             api.CapabilityStatus(d.Get("push_profile_updates").(bool)),
+		},
+	}
+}
+
+// takeAwsIdentityCenterSecrets returns the SCIM credentials and blanks them
+// in the resource data, so they are never saved to state, even when a later
+// call to Okta fails.
+func takeAwsIdentityCenterSecrets(d *schema.ResourceData) (string, string) {
+	values := secretValues(d, awsIdentityCenterSecrets...)
+	for _, key := range awsIdentityCenterSecrets {
+		d.Set(key, "")
+	}
+	return values[0], values[1]
+}
+
+// setAwsIdentityCenterProvisioning connects the app to the SCIM endpoint, or
+// disconnects it when no endpoint is configured, and keeps the hash of the
+// credentials in place of them.
+func setAwsIdentityCenterProvisioning(ctx context.Context, d *schema.ResourceData, client api.OktaAPI, endpoint, token string) error {
+	salt := d.Get("scim_credentials_salt").(string)
+	if salt == "" {
+		var err error
+		if salt, err = newSecretSalt(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case endpoint == "" && token == "":
+		if d.Get("scim_status").(string) == "ENABLED" {
+			if err := client.DeactivateProvisioningConnection(ctx, d.Id()); err != nil {
+				return err
+			}
+		}
+	case endpoint == "" || token == "":
+		return fmt.Errorf("Both scim_endpoint_url and scim_access_token must be provided")
+	default:
+		if _, err := client.SetProvisioningConnection(ctx, d.Id(), endpoint, token); err != nil {
+			return err
+		}
+
+		if _, err := client.UpdateProvisioningFeature(ctx, d.Id(), awsIdentityCenterCapabilities(d)); err != nil {
+			return err
+		}
+	}
+
+	d.Set("scim_credentials_salt", salt)
+	d.Set("scim_credentials_hash", hashSecret(salt, endpoint, token))
+	return nil
+}
+
+func resourceAppAwsIdentityCenterCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	endpoint, token := takeAwsIdentityCenterSecrets(d)
+
+	acsURL, issuerURL, err := awsIdentityCenterSAML(d)
+	if err != nil {
+		return err
+	}
+
+	application := api.NewAwsIdentityCenterApplication(d.Get("name").(string), acsURL, issuerURL)
+	applyAppSettings(d, &application)
+
+	created, err := client.CreateApplication(ctx, application)
+	if err != nil {
+		return err
+	}
+
+	d.SetId(created.ID)
+	if err := setAwsIdentityCenterProvisioning(ctx, d, client, endpoint, token); err != nil {
+		return err
+	}
+
+	return resourceAppAwsIdentityCenterRead(ctx, d, m)
+}
+
+func resourceAppAwsIdentityCenterRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	appID := d.Id()
+
+	app, err := client.GetApplication(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	if app == nil {
+		log.Printf("[WARN] Okta Application not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	saml, err := client.GetSAMLMetadata(ctx, app.ID, app.SigningKeyID())
+	if err != nil {
+		return err
+	}
+
+	connection, err := client.GetProvisioningConnection(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	d.Set("application_id", app.ID)
+	d.Set("name", app.Label)
+	d.Set("label", app.Label)
+	d.Set("sign_on_mode", app.SignOnMode)
+	d.Set("acs_url", app.Settings.App.AcsURL)
+	d.Set("issuer_url", app.Settings.App.EntityID)
+	d.Set("saml_metadata_document", saml)
+	setAppSettings(d, app)
+
+	status := ""
+	if connection != nil {
+		status = connection.Status
+	}
+	d.Set("scim_status", status)
+
+	if status != "ENABLED" {
+		return nil
+	}
+
+	feature, err := client.GetProvisioningFeature(ctx, app.ID)
+	if err != nil {
+		return err
+	}
+
+	if feature != nil {
+		d.Set("push_new_users", feature.Capabilities.Create.LifecycleCreate.Enabled())
+		d.Set("push_profile_updates", feature.Capabilities.Update.Profile.Enabled())
+		d.Set("deactivate_users", feature.Capabilities.Update.LifecycleDeactivate.Enabled())
+	}
+
+	return nil
+}
+
+func resourceAppAwsIdentityCenterUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) (err error) {
+	config := m.(*Config)
+	client := config.Okta
+	endpoint, token := takeAwsIdentityCenterSecrets(d)
+
+	// The previous hash is kept when the update fails, so the credentials
+	// are sent again on the next apply.
+	defer func() {
+		if err != nil {
+			previous, _ := d.GetChange("scim_credentials_hash")
+			d.Set("scim_credentials_hash", previous)
+		}
+	}()
+
+	acsURL, issuerURL, err := awsIdentityCenterSAML(d)
+	if err != nil {
+		return err
+	}
+
+	application := api.NewAwsIdentityCenterApplication(d.Get("name").(string), acsURL, issuerURL)
+	application.ID = d.Id()
+	applyAppSettings(d, &application)
+
+	if _, err := client.UpdateApplication(ctx, application); err != nil {
+		return err
+	}
+
+	// The SCIM secrets are only in the diff when they have changed, the
+	// provisioning settings can change without them.
+	if d.HasChange("scim_credentials_hash") {
+		if err := setAwsIdentityCenterProvisioning(ctx, d, client, endpoint, token); err != nil {
+			return err
+		}
+	} else if d.Get("scim_status").(string) == "ENABLED" {
+		for _, key := range []string{"push_new_users", "push_profile_updates", "deactivate_users"} {
+			if !d.HasChange(key) {
+				continue
+			}
+
+			if _, err := client.UpdateProvisioningFeature(ctx, d.Id(), awsIdentityCenterCapabilities(d)); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return resourceAppAwsIdentityCenterRead(ctx, d, m)
+}
+
+func resourceAppAwsIdentityCenterDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	appID := d.Id()
+
+	err := client.DeactivateApplication(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteApplication(ctx, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
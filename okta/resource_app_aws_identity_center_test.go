@@ -0,0 +1,217 @@
+package okta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const testIdentityCenterMetadata = `<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://us-east-1.signin.aws.amazon.com/platform/saml/d-1234567890">
+  <md:SPSSODescriptor AuthnRequestsSigned="false" WantAssertionsSigned="true" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:AssertionConsumerService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://us-east-1.signin.aws.amazon.com/platform/saml/acs/d-1234567890" index="0"/>
+  </md:SPSSODescriptor>
+</md:EntityDescriptor>`
+
+func TestResourceAppAwsIdentityCenter_lifecycle(t *testing.T) {
+	providerConfig, okta, _ := testFakeConfig()
+	r := resourceAppAwsIdentityCenter()
+
+	values := map[string]interface{}{
+		"name":                     "ACME-IdentityCenter",
+		"identity_center_metadata": testIdentityCenterMetadata,
+		"scim_endpoint_url":        "https://scim.us-east-1.amazonaws.com/abcd1234/scim/v2",
+		"scim_access_token":        "secret",
+		"push_profile_updates":     false,
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	app := okta.Applications[d.Id()]
+	if app == nil || app.Name != "amazon_aws_sso" || app.Label != "ACME-IdentityCenter" {
+		t.Fatalf("expected an IAM Identity Center app to be created, got %+v", app)
+	}
+
+	if app.Settings.App.AcsURL != "https://us-east-1.signin.aws.amazon.com/platform/saml/acs/d-1234567890" {
+		t.Fatalf("expected the ACS URL from the metadata, got %q", app.Settings.App.AcsURL)
+	}
+
+	if app.Settings.App.EntityID != "https://us-east-1.signin.aws.amazon.com/platform/saml/d-1234567890" {
+		t.Fatalf("expected the issuer from the metadata, got %q", app.Settings.App.EntityID)
+	}
+
+	connection := okta.Connections[d.Id()]
+	if connection == nil || connection.Status != "ENABLED" || connection.Profile.Token != "secret" {
+		t.Fatalf("expected SCIM provisioning to be enabled, got %+v", connection)
+	}
+
+	feature := okta.Features[d.Id()]
+	if feature == nil || !feature.Capabilities.Create.LifecycleCreate.Enabled() || feature.Capabilities.Update.Profile.Enabled() {
+		t.Fatalf("expected new users but not profile updates to be pushed, got %+v", feature)
+	}
+
+	if d.Get("scim_access_token").(string) != "" || d.Get("scim_endpoint_url").(string) != "" {
+		t.Fatalf("expected the SCIM credentials to be kept out of state")
+	}
+
+	if d.Get("scim_status").(string) != "ENABLED" {
+		t.Fatalf("expected scim_status ENABLED, got %q", d.Get("scim_status"))
+	}
+
+	state := d.State()
+
+	diff, err := r.Diff(state, testResourceConfig(t, values), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for unchanged credentials, got %#v", diff)
+	}
+
+	values["scim_access_token"] = "rotated"
+	diff, err = r.Diff(state, testResourceConfig(t, values), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if diff.Empty() || diff.RequiresNew() {
+		t.Fatalf("expected a rotated token to be updated in place, got %#v", diff)
+	}
+
+	if state, err = r.Apply(state, diff, providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Connections[state.ID].Profile.Token != "rotated" {
+		t.Fatalf("expected the rotated token to be set, got %q", okta.Connections[state.ID].Profile.Token)
+	}
+
+	if state.Attributes["scim_access_token"] != "" {
+		t.Fatalf("expected the rotated token to be kept out of state")
+	}
+
+	delete(values, "scim_endpoint_url")
+	delete(values, "scim_access_token")
+	diff, err = r.Diff(state, testResourceConfig(t, values), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Connections[state.ID].Status != "DISABLED" || state.Attributes["scim_status"] != "DISABLED" {
+		t.Fatalf("expected SCIM provisioning to be disabled, got %+v", okta.Connections[state.ID])
+	}
+
+	d = r.Data(state)
+	if err := r.Delete(d, providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Applications[state.ID]; ok {
+		t.Fatalf("expected application %s to be deleted", state.ID)
+	}
+}
+
+func TestResourceAppAwsIdentityCenter_failedApplyKeepsSecretsOutOfState(t *testing.T) {
+	providerConfig, okta, _ := testFakeConfig()
+	r := resourceAppAwsIdentityCenter()
+
+	values := map[string]interface{}{
+		"name":                     "ACME-IdentityCenter",
+		"identity_center_metadata": testIdentityCenterMetadata,
+		"scim_endpoint_url":        "https://scim.us-east-1.amazonaws.com/abcd1234/scim/v2",
+		"scim_access_token":        "secret",
+	}
+
+	okta.Errors["SetProvisioningConnection"] = fmt.Errorf("SetProvisioningConnection failed")
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, providerConfig); err == nil {
+		t.Fatalf("expected the create to fail")
+	}
+
+	if d.Id() == "" {
+		t.Fatalf("expected the created application to be kept in state")
+	}
+
+	if d.Get("scim_access_token").(string) != "" || d.Get("scim_endpoint_url").(string) != "" {
+		t.Fatalf("expected the SCIM credentials to be kept out of state after a failed create")
+	}
+
+	delete(okta.Errors, "SetProvisioningConnection")
+	d = schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state := d.State()
+	hash := state.Attributes["scim_credentials_hash"]
+
+	values["scim_access_token"] = "rotated"
+	diff, err := r.Diff(state, testResourceConfig(t, values), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, method := range []string{"UpdateApplication", "SetProvisioningConnection"} {
+		okta.Errors[method] = fmt.Errorf("%s failed", method)
+		failed, err := r.Apply(state, diff, providerConfig)
+		if err == nil {
+			t.Fatalf("expected the update to fail when %s fails", method)
+		}
+		delete(okta.Errors, method)
+
+		if failed.Attributes["scim_access_token"] != "" || failed.Attributes["scim_endpoint_url"] != "" {
+			t.Fatalf("expected the SCIM credentials to be kept out of state when %s fails", method)
+		}
+
+		if failed.Attributes["scim_credentials_hash"] != hash {
+			t.Fatalf("expected the previous hash to be kept when %s fails, so the token is sent again", method)
+		}
+	}
+
+	if okta.Connections[state.ID].Profile.Token != "secret" {
+		t.Fatalf("expected the token to be unchanged, got %q", okta.Connections[state.ID].Profile.Token)
+	}
+}
+
+func TestResourceAppAwsIdentityCenter_requiresSAMLSettings(t *testing.T) {
+	providerConfig, okta, _ := testFakeConfig()
+	r := resourceAppAwsIdentityCenter()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":    "ACME-IdentityCenter",
+		"acs_url": "https://us-east-1.signin.aws.amazon.com/platform/saml/acs/d-1234567890",
+	})
+
+	if err := r.Create(d, providerConfig); err == nil || !strings.Contains(err.Error(), "acs_url and issuer_url") {
+		t.Fatalf("expected an error without an issuer, got %v", err)
+	}
+
+	if len(okta.Applications) != 0 {
+		t.Fatalf("expected no application to be created")
+	}
+}
+
+func TestResourceAppAwsIdentityCenter_requiresBothSCIMCredentials(t *testing.T) {
+	providerConfig, _, _ := testFakeConfig()
+	r := resourceAppAwsIdentityCenter()
+
+	_, err := r.Diff(nil, testResourceConfig(t, map[string]interface{}{
+		"name":                     "ACME-IdentityCenter",
+		"identity_center_metadata": testIdentityCenterMetadata,
+		"scim_endpoint_url":        "https://scim.us-east-1.amazonaws.com/abcd1234/scim/v2",
+	}), providerConfig)
+
+	if err == nil || !strings.Contains(err.Error(), "Both scim_endpoint_url and scim_access_token") {
+		t.Fatalf("expected an error without the SCIM token, got %v", err)
+	}
+}
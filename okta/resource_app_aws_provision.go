@@ -1,23 +1,85 @@
 package okta
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
 
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"github.com/hashicorp/terraform/helper/schema"
-	"github.com/matryer/try"
 )
 
-const RetryWaitTimeInSeconds = 45 * time.Second
-
 func resourceAppAwsProvision() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceAppAwsProvisionCreate,
-		Read:   resourceAppAwsProvisionRead,
-		Delete: resourceAppAwsProvisionDelete,
+		Create:        withTimeout(schema.TimeoutCreate, resourceAppAwsProvisionCreate),
+		Read:          withTimeout(schema.TimeoutRead, resourceAppAwsProvisionRead),
+		Delete:        withTimeout(schema.TimeoutDelete, resourceAppAwsProvisionDelete),
+		CustomizeDiff: resourceAppAwsProvisionCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Type:    resourceAppAwsProvisionV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceAppAwsProvisionStateUpgradeV0,
+				Version: 0,
+			},
+		},
 
+		Schema: map[string]*schema.Schema{
+			"application_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"aws_access_key": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressStoredSecret,
+				ConflictsWith:    []string{"aws_profile"},
+			},
+			"aws_secret_key": &schema.Schema{
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				Sensitive:        true,
+				DiffSuppressFunc: suppressStoredSecret,
+				ConflictsWith:    []string{"aws_profile"},
+			},
+			"aws_profile": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The profile in the AWS shared credentials file to source the keys from",
+			},
+			"aws_shared_credentials_file": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "The AWS shared credentials file, defaults to ~/.aws/credentials",
+			},
+			"credentials_salt": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"credentials_hash": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Salted hash of the AWS keys, used to detect a change of credentials",
+			},
+		},
+	}
+}
+
+func resourceAppAwsProvisionV0() *schema.Resource {
+	return &schema.Resource{
 		Schema: map[string]*schema.Schema{
 			"application_id": &schema.Schema{
 				Type:     schema.TypeString,
@@ -40,52 +102,101 @@ func resourceAppAwsProvision() *schema.Resource {
 	}
 }
 
-func resourceAppAwsProvisionCreate(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+// The first version kept the AWS keys in plain text, replace them with
+// their hash.
+func resourceAppAwsProvisionStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	salt, err := newSecretSalt()
+	if err != nil {
+		return nil, err
+	}
+
+	accessKey, _ := rawState["aws_access_key"].(string)
+	secretKey, _ := rawState["aws_secret_key"].(string)
+
+	rawState["credentials_salt"] = salt
+	rawState["credentials_hash"] = hashSecret(salt, accessKey, secretKey)
+	rawState["aws_access_key"] = ""
+	rawState["aws_secret_key"] = ""
+
+	return rawState, nil
+}
+
+func resourceAppAwsProvisionCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if config, ok := m.(*Config); ok {
+		if err := config.Web.Validate(); err != nil {
+			return err
+		}
+	}
+
+	keys := []string{"aws_access_key", "aws_secret_key", "aws_profile", "aws_shared_credentials_file"}
+	for _, key := range keys {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+
+	accessKey, secretKey, err := resolveAwsCredentials(d)
+	if err != nil {
+		return err
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	hash := hashSecret(d.Get("credentials_salt").(string), accessKey, secretKey)
+	if hash == d.Get("credentials_hash").(string) {
+		return nil
+	}
+
+	log.Printf("[INFO] AWS credentials for application %s have changed", d.Id())
+	if err := d.SetNew("credentials_hash", hash); err != nil {
+		return err
+	}
+
+	return d.ForceNew("credentials_hash")
+}
+
+func resourceAppAwsProvisionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 	web := config.Web
 
 	appId := d.Get("application_id").(string)
-	awsKey := d.Get("aws_access_key").(string)
-	awsSecret := d.Get("aws_secret_key").(string)
-
-	application, err := client.GetApplication(appId)
+	awsKey, awsSecret, err := resolveAwsCredentials(d)
 	if err != nil {
 		return err
 	}
 
-	err = try.Do(func(ampt int) (bool, error) {
-		err := web.SetAWSProvisioning(application.ID, awsKey, awsSecret)
-		if err != nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
-		}
-
-		app, err := client.GetApplication(application.ID)
-		if err != nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
-		}
+	salt, err := newSecretSalt()
+	if err != nil {
+		return err
+	}
 
-		err = applicationIsProvisioned(app)
-		if err != nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
-		}
+	application, err := client.GetApplication(ctx, appId)
+	if err != nil {
+		return err
+	}
 
-		return ampt < client.RetryMaximum, nil
-	})
+	err = web.SetAWSProvisioning(ctx, application.ID, awsKey, awsSecret)
+	if err == nil {
+		err = waitForAwsProvisioning(ctx, config, application.ID, true)
+	}
 
 	d.SetId(application.ID)
+	d.Set("aws_access_key", "")
+	d.Set("aws_secret_key", "")
+	d.Set("credentials_salt", salt)
+	d.Set("credentials_hash", hashSecret(salt, awsKey, awsSecret))
 	return err
 }
 
-func resourceAppAwsProvisionRead(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppAwsProvisionRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 	appID := d.Id()
 
-	readApplication, err := client.GetApplication(appID)
+	readApplication, err := client.GetApplication(ctx, appID)
 	if err != nil {
 		return err
 	}
@@ -96,7 +207,7 @@ func resourceAppAwsProvisionRead(d *schema.ResourceData, m interface{}) error {
 		return nil
 	}
 
-	samlMetadataDocument, err := client.GetSAMLMetadata(appID, readApplication.Credentials.Signing.KeyID)
+	samlMetadataDocument, err := client.GetSAMLMetadata(ctx, appID, readApplication.SigningKeyID())
 	if err != nil {
 		return err
 	}
@@ -107,13 +218,12 @@ func resourceAppAwsProvisionRead(d *schema.ResourceData, m interface{}) error {
 	d.Set("aws_environment_type", readApplication.Settings.App.AwsEnvironmentType)
 	d.Set("group_filter", readApplication.Settings.App.GroupFilter)
 	d.Set("login_url", readApplication.Settings.App.LoginURL)
-	d.Set("join_all_roles", readApplication.Settings.App.JoinAllRoles)
+	d.Set("join_all_roles", api.BoolValue(readApplication.Settings.App.JoinAllRoles))
 	d.Set("identity_provider_arn", readApplication.Settings.App.IdentityProviderArn)
 	d.Set("session_duration", readApplication.Settings.App.SessionDuration)
 	d.Set("role_value_pattern", readApplication.Settings.App.RoleValuePattern)
 	d.Set("saml_metadata_document", samlMetadataDocument)
 
-	fmt.Printf("%+v\n", readApplication)
 	return nil
 }
 
@@ -126,33 +236,57 @@ func applicationIsProvisioned(app *api.OktaApplication) error {
 	return fmt.Errorf("PUSH_NEW_USERS is not configured")
 }
 
-func resourceAppAwsProvisionDelete(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
-	client := config.Okta
+func resourceAppAwsProvisionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	web := config.Web
 	appID := d.Id()
 
-	err := try.Do(func(ampt int) (bool, error) {
-		err := web.RevokeAWSProvisioning(appID)
+	if err := web.RevokeAWSProvisioning(ctx, appID); err != nil {
+		return err
+	}
+
+	return waitForAwsProvisioning(ctx, config, appID, false)
+}
+
+// awsProvisioningPollInterval is how often the app is read while waiting
+// for a change to provisioning to show.
+var awsProvisioningPollInterval = 10 * time.Second
+
+// waitForAwsProvisioning polls the app at a fixed interval until Okta shows
+// provisioning as enabled or revoked, for as long as the timeout of the
+// operation allows. The requests themselves are already retried by the
+// clients, so the wait doesn't depend on how many retries they are allowed.
+func waitForAwsProvisioning(ctx context.Context, config *Config, appID string, enabled bool) error {
+	for {
+		app, err := config.Okta.GetApplication(ctx, appID)
 		if err != nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
+			return err
 		}
 
-		app, err := client.GetApplication(appID)
-		if err != nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
+		if app == nil {
+			if enabled {
+				return fmt.Errorf("Okta Application %s not found", appID)
+			}
+			return nil
 		}
 
 		err = applicationIsProvisioned(app)
-		if err == nil {
-			time.Sleep(RetryWaitTimeInSeconds)
-			return ampt < client.RetryMaximum, err
+		if enabled == (err == nil) {
+			return nil
 		}
 
-		return ampt < client.RetryMaximum, nil
-	})
+		if !enabled {
+			err = fmt.Errorf("PUSH_NEW_USERS is still configured")
+		}
 
-	return err
+		log.Printf("[DEBUG] Waiting %s for provisioning of app (%s) to change: %s", awsProvisioningPollInterval, appID, err)
+
+		timer := time.NewTimer(awsProvisioningPollInterval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
 }
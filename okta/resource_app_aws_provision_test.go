@@ -0,0 +1,154 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceAppAwsProvision_lifecycle(t *testing.T) {
+	config, okta, web := testFakeConfig()
+	r := resourceAppAwsProvision()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": app.ID,
+		"aws_access_key": "AKIAEXAMPLE",
+		"aws_secret_key": "secret",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if web.Credentials[app.ID] != [2]string{"AKIAEXAMPLE", "secret"} {
+		t.Fatalf("expected provisioning to be set with the AWS keys, got %v", web.Credentials[app.ID])
+	}
+
+	if d.Get("aws_access_key").(string) != "" || d.Get("aws_secret_key").(string) != "" {
+		t.Fatalf("expected the AWS keys to be kept out of state")
+	}
+
+	salt := d.Get("credentials_salt").(string)
+	if d.Get("credentials_hash").(string) != hashSecret(salt, "AKIAEXAMPLE", "secret") {
+		t.Fatalf("expected the hash of the AWS keys to be stored")
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := web.Credentials[app.ID]; ok {
+		t.Fatalf("expected provisioning to be revoked")
+	}
+}
+
+func TestResourceAppAwsProvision_createError(t *testing.T) {
+	config, okta, web := testFakeConfig()
+	web.Errors["SetAWSProvisioning"] = fmt.Errorf("Provisioning did not yield successful 500")
+	r := resourceAppAwsProvision()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"application_id": app.ID,
+		"aws_access_key": "AKIAEXAMPLE",
+		"aws_secret_key": "secret",
+	})
+
+	if err := r.Create(d, config); err == nil {
+		t.Fatalf("expected an error when provisioning fails")
+	}
+}
+
+func TestResourceAppAwsProvision_waitsForProvisioning(t *testing.T) {
+	config, okta, web := testFakeConfig()
+	config.RetryPolicy = api.RetryPolicy{MaxRetries: 0}
+
+	interval := awsProvisioningPollInterval
+	awsProvisioningPollInterval = 10 * time.Millisecond
+	defer func() { awsProvisioningPollInterval = interval }()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+
+	// Provisioning shows a few polls after it is set, which is waited on
+	// however many retries the requests are allowed.
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		web.SetAWSProvisioning(context.Background(), app.ID, "AKIAEXAMPLE", "secret")
+	}()
+
+	if err := waitForAwsProvisioning(context.Background(), config, app.ID, true); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The wait ends with the operation's timeout.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := waitForAwsProvisioning(ctx, config, app.ID, false)
+	if err == nil || !strings.Contains(err.Error(), "PUSH_NEW_USERS is still configured") {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+}
+
+func TestResourceAppAwsProvision_diff(t *testing.T) {
+	providerConfig, _, web := testFakeConfig()
+	r := resourceAppAwsProvision()
+
+	salt := "salt"
+	state := &terraform.InstanceState{
+		ID: "0oa1",
+		Attributes: map[string]string{
+			"id":               "0oa1",
+			"application_id":   "0oa1",
+			"aws_access_key":   "",
+			"aws_secret_key":   "",
+			"credentials_salt": salt,
+			"credentials_hash": hashSecret(salt, "AKIAEXAMPLE", "secret"),
+		},
+	}
+
+	resourceConfig := func(secret string) *terraform.ResourceConfig {
+		raw, err := config.NewRawConfig(map[string]interface{}{
+			"application_id": "0oa1",
+			"aws_access_key": "AKIAEXAMPLE",
+			"aws_secret_key": secret,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return terraform.NewResourceConfig(raw)
+	}
+
+	diff, err := r.Diff(state, resourceConfig("secret"), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for unchanged keys, got %#v", diff)
+	}
+
+	diff, err = r.Diff(state, resourceConfig("rotated"), providerConfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if diff.Empty() || !diff.RequiresNew() {
+		t.Fatalf("expected changed keys to replace the resource, got %#v", diff)
+	}
+
+	web.Errors["Validate"] = fmt.Errorf("missing credentials")
+	if _, err := r.Diff(state, resourceConfig("secret"), providerConfig); err == nil {
+		t.Fatalf("expected an error when the admin credentials are missing")
+	}
+}
@@ -0,0 +1,411 @@
+package okta
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceAppAws_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":                  "ACME-AwsAccount",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	app, ok := okta.Applications[d.Id()]
+	if !ok {
+		t.Fatalf("expected application %s to be created", d.Id())
+	}
+
+	if app.Label != "ACME-AwsAccount" {
+		t.Fatalf("expected label ACME-AwsAccount, got %s", app.Label)
+	}
+
+	if d.Get("saml_metadata_document").(string) != okta.Metadata[d.Id()] {
+		t.Fatalf("expected the SAML metadata to be read, got %q", d.Get("saml_metadata_document"))
+	}
+
+	d.Set("name", "ACME-Renamed")
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Applications[d.Id()].Label != "ACME-Renamed" {
+		t.Fatalf("expected label ACME-Renamed, got %s", okta.Applications[d.Id()].Label)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Applications[d.Id()]; ok {
+		t.Fatalf("expected application %s to be deleted", d.Id())
+	}
+}
+
+func TestResourceAppAws_readRemovesMissingApplication(t *testing.T) {
+	config, _, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	d.SetId("0oamissing")
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("expected the application to be removed from state")
+	}
+}
+
+func TestResourceAppAws_errors(t *testing.T) {
+	for _, method := range []string{"CreateApplication", "GetApplication", "GetSAMLMetadata", "GetAppUserSchema"} {
+		config, okta, _ := testFakeConfig()
+		okta.Errors[method] = fmt.Errorf("%s failed", method)
+		r := resourceAppAws()
+
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"name":                  "ACME-AwsAccount",
+			"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA",
+		})
+
+		if err := r.Create(d, config); err == nil {
+			t.Fatalf("expected an error when %s fails", method)
+		}
+	}
+}
+
+func TestResourceAppAws_visibilityAndAccessibility(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":                  "ACME-AwsAccount",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA",
+		"hide_web":              true,
+		"login_redirect_url":    "https://example.com/login",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	app := okta.Applications[d.Id()]
+	if !app.Visibility.Hide.Web || app.Visibility.Hide.IOS || api.StringValue(app.Accessibility.LoginRedirectURL) != "https://example.com/login" {
+		t.Fatalf("unexpected visibility %+v and accessibility %+v", app.Visibility, app.Accessibility)
+	}
+
+	// Settings changed in the console are kept when they aren't configured.
+	app.Visibility.Hide.IOS = true
+	app.Accessibility.ErrorRedirectURL = api.String("https://example.com/error")
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	d.Set("self_service", true)
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	app = okta.Applications[d.Id()]
+	if !app.Visibility.Hide.IOS || !app.Visibility.Hide.Web || !app.Accessibility.SelfService || api.StringValue(app.Accessibility.ErrorRedirectURL) != "https://example.com/error" {
+		t.Fatalf("unexpected visibility %+v and accessibility %+v", app.Visibility, app.Accessibility)
+	}
+
+	if !d.Get("hide_ios").(bool) || d.Get("error_redirect_url").(string) != "https://example.com/error" {
+		t.Fatalf("expected the settings to be read back into state")
+	}
+}
+
+func TestResourceAppAws_clearsRedirectURL(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	values := map[string]interface{}{
+		"name":                  "ACME-AwsAccount",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA",
+		"login_redirect_url":    "https://example.com/login",
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state := d.State()
+	delete(values, "login_redirect_url")
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if attr := diff.Attributes["login_redirect_url"]; attr == nil || attr.New != "" {
+		t.Fatalf("expected clearing the login redirect URL to be planned, got %#v", diff)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if url := okta.Applications[state.ID].Accessibility.LoginRedirectURL; url == nil || *url != "" {
+		t.Fatalf("expected the login redirect URL to be cleared, got %v", url)
+	}
+
+	if state.Attributes["login_redirect_url"] != "" {
+		t.Fatalf("expected the cleared login redirect URL in state, got %q", state.Attributes["login_redirect_url"])
+	}
+}
+
+func TestResourceAppAws_userNameTemplate(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":                    "ACME-AwsAccount",
+		"identity_provider_arn":   "arn:aws:iam::123412341234:saml-provider/OKTA",
+		"user_name_template_type": "CUSTOM",
+	})
+	d.Set("user_name_template", "${source.awsUsername}")
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	app := okta.Applications[d.Id()]
+	if app.Credentials.UserNameTemplate.Template != "${source.awsUsername}" || app.Credentials.UserNameTemplate.Type != "CUSTOM" {
+		t.Fatalf("unexpected user name template %+v", app.Credentials.UserNameTemplate)
+	}
+
+	if app.SigningKeyID() == "" {
+		t.Fatalf("expected the signing key to be kept alongside the user name template")
+	}
+
+	// A template changed in the console is read back, so the drift shows in
+	// the plan.
+	app.Credentials.UserNameTemplate.Template = "${source.email}"
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Get("user_name_template").(string) != "${source.email}" {
+		t.Fatalf("expected the template to be read back, got %q", d.Get("user_name_template"))
+	}
+}
+
+func TestResourceAppAws_clearsUserNameSuffix(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	values := map[string]interface{}{
+		"name":                      "ACME-AwsAccount",
+		"identity_provider_arn":     "arn:aws:iam::123412341234:saml-provider/OKTA",
+		"user_name_template_suffix": "@example.com",
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state := d.State()
+	delete(values, "user_name_template_suffix")
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if attr := diff.Attributes["user_name_template_suffix"]; attr == nil || attr.New != "" {
+		t.Fatalf("expected clearing the user name suffix to be planned, got %#v", diff)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if suffix := okta.Applications[state.ID].Credentials.UserNameTemplate.Suffix; suffix == nil || *suffix != "" {
+		t.Fatalf("expected the user name suffix to be cleared, got %v", suffix)
+	}
+}
+
+func TestResourceAppAws_awsEnvironment(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":                  "ACME-AwsAccount",
+		"identity_provider_arn": "arn:aws-cn:iam::123412341234:saml-provider/OKTA",
+		"aws_environment_type":  "aws.cn",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	settings := okta.Applications[d.Id()].Settings.App
+	if settings.AwsEnvironmentType != "aws.cn" || settings.LoginURL != "https://console.amazonaws.cn/console/home" {
+		t.Fatalf("expected the app to use the AWS China console, got %+v", settings)
+	}
+
+	if !strings.HasPrefix(settings.RoleValuePattern, "arn:aws-cn:iam::${accountid}:saml-provider/OKTA,arn:aws-cn:iam::") {
+		t.Fatalf("expected the role ARNs to be in the aws-cn partition, got %s", settings.RoleValuePattern)
+	}
+
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Applications[d.Id()].Settings.App.AwsEnvironmentType != "aws.cn" {
+		t.Fatalf("expected an update to keep the environment")
+	}
+}
+
+func TestResourceAppAws_validatesIdentityProviderArn(t *testing.T) {
+	r := resourceAppAws()
+
+	resourceConfig := func(values map[string]interface{}) *terraform.ResourceConfig {
+		raw, err := config.NewRawConfig(values)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return terraform.NewResourceConfig(raw)
+	}
+
+	accounts := resourceConfig(map[string]interface{}{
+		"name":                  "ACME-AwsAccounts",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA, arn:aws:iam::567856785678:saml-provider/OKTA",
+	})
+	if _, err := r.Diff(nil, accounts, nil); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	govCloud := resourceConfig(map[string]interface{}{
+		"name":                  "ACME-AwsAccounts",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA,arn:aws-us-gov:iam::567856785678:saml-provider/OKTA",
+		"aws_environment_type":  "aws.us-gov",
+	})
+	_, err := r.Diff(nil, govCloud, nil)
+	if err == nil || !strings.Contains(err.Error(), `"arn:aws:iam::123412341234:saml-provider/OKTA" is in partition aws, but the app's environment aws.us-gov is in aws-us-gov`) {
+		t.Fatalf("expected the ARN in the wrong partition to be named, got %v", err)
+	}
+}
+
+func TestResourceAppAws_switchesPartition(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	values := map[string]interface{}{
+		"name":                  "ACME-AwsAccount",
+		"identity_provider_arn": "arn:aws:iam::123412341234:saml-provider/OKTA",
+	}
+	diff, err := r.Diff(nil, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state, err := r.Apply(nil, diff, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The ARN read back into identity_provider_arn is still in the aws
+	// partition, which mustn't fail the switch to the new one.
+	values = map[string]interface{}{
+		"name":                   "ACME-AwsAccount",
+		"identity_provider_arns": []interface{}{"arn:aws-cn:iam::123412341234:saml-provider/OKTA"},
+		"aws_environment_type":   "aws.cn",
+	}
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	settings := okta.Applications[state.ID].Settings.App
+	if settings.AwsEnvironmentType != "aws.cn" || settings.IdentityProviderArn != "arn:aws-cn:iam::123412341234:saml-provider/OKTA" {
+		t.Fatalf("expected the app to be moved to the aws-cn partition, got %+v", settings)
+	}
+
+	values["identity_provider_arns"] = []interface{}{"arn:aws:iam::123412341234:saml-provider/OKTA"}
+	if _, err := r.Diff(state, testResourceConfig(t, values), config); err == nil || !strings.Contains(err.Error(), "is in partition aws") {
+		t.Fatalf("expected a changed ARN in the wrong partition to be rejected, got %v", err)
+	}
+}
+
+func TestResourceAppAws_multipleAccounts(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name": "ACME-AwsAccounts",
+		"identity_provider_arns": []interface{}{
+			"arn:aws:iam::567856785678:saml-provider/OKTA",
+			"arn:aws:iam::123412341234:saml-provider/OKTA",
+		},
+		"join_all_roles": true,
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	settings := okta.Applications[d.Id()].Settings.App
+	if settings.IdentityProviderArn != "arn:aws:iam::123412341234:saml-provider/OKTA,arn:aws:iam::567856785678:saml-provider/OKTA" {
+		t.Fatalf("expected the ARNs to be comma separated, got %s", settings.IdentityProviderArn)
+	}
+
+	if !api.BoolValue(settings.JoinAllRoles) || !d.Get("join_all_roles").(bool) {
+		t.Fatalf("expected the app to join all roles")
+	}
+
+	appSchema := &api.OktaAppUserSchema{}
+	appSchema.Definitions.Base.Properties = map[string]api.OktaSchemaProperty{
+		"samlRoles": {Type: "array", Items: &api.OktaSchemaProperty{Type: "string", OneOf: []api.OktaSchemaOption{
+			{Const: "arn:aws:iam::567856785678:saml-provider/OKTA,arn:aws:iam::567856785678:role/Admin", Title: "Admin"},
+			{Const: "arn:aws:iam::123412341234:saml-provider/OKTA,arn:aws:iam::123412341234:role/ReadOnly", Title: "ReadOnly"},
+			{Const: "arn:aws:iam::123412341234:saml-provider/OKTA,arn:aws:iam::123412341234:role/Admin", Title: "Admin"},
+		}}},
+	}
+	okta.Schemas[d.Id()] = appSchema
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Get("account_roles.#").(int) != 2 || d.Get("account_roles.0.account_id").(string) != "123412341234" {
+		t.Fatalf("expected the roles of both accounts, got %v", d.Get("account_roles"))
+	}
+
+	roles := d.Get("account_roles.0.roles").([]interface{})
+	if len(roles) != 2 || roles[0].(string) != "Admin" || roles[1].(string) != "ReadOnly" {
+		t.Fatalf("unexpected roles for account 123412341234, got %v", roles)
+	}
+}
+
+func TestResourceAppAws_requiresIdentityProviderArn(t *testing.T) {
+	config, _, _ := testFakeConfig()
+	r := resourceAppAws()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name": "ACME-AwsAccount",
+	})
+
+	if err := r.Create(d, config); err == nil || !strings.Contains(err.Error(), "One of identity_provider_arn or identity_provider_arns") {
+		t.Fatalf("expected an error without an identity provider ARN, got %v", err)
+	}
+}
@@ -1,22 +1,39 @@
 package okta
 
 import (
-	"github.com/hashicorp/terraform/helper/schema"
+	"context"
+	"encoding/json"
+	"fmt"
 	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 )
 
 func resourceAppUserAttachment() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceAppUserAttachmentCreate,
-		Read:   resourceAppUserAttachmentRead,
-		Update: resourceAppUserAttachmentUpdate,
-		Delete: resourceAppUserAttachmentDelete,
+		Create: withTimeout(schema.TimeoutCreate, resourceAppUserAttachmentCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAppUserAttachmentRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAppUserAttachmentUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAppUserAttachmentDelete),
+
+		CustomizeDiff: resourceAppUserAttachmentCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"role": &schema.Schema{
 				Type:     schema.TypeString,
-				ForceNew: true,
-				Required: true,
+				Optional: true,
 			},
 			"user": &schema.Schema{
 				Type:     schema.TypeString,
@@ -39,67 +56,321 @@ func resourceAppUserAttachment() *schema.Resource {
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
-				Required: true,
+				Optional: true,
+			},
+			"profile": &schema.Schema{
+				Type:             schema.TypeMap,
+				Optional:         true,
+				Elem:             &schema.Schema{Type: schema.TypeString},
+				ValidateFunc:     validateAppUserProfile,
+				DiffSuppressFunc: suppressEquivalentJSON,
+				Description:      "Other attributes of the user's profile in the app, each JSON encoded, such as sessionDuration",
+			},
+			"send_email": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Email the user when they are unassigned from the app on destroy",
+			},
+			"retain_downstream": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "On destroy, when the app deprovisions users, leave the user assigned with their role, SAML roles and profile cleared so their account in the app is kept",
+			},
+			"destroy_dry_run": &schema.Schema{
+				Type:         schema.TypeBool,
+				Optional:     true,
+				Default:      false,
+				ValidateFunc: warnDestroyDryRun,
+				Description:  "Leave the user assigned when the attachment is destroyed or replaced, and log what destroying it would do, in Okta and in the app, instead",
+			},
+			"status": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"scope": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "USER",
+				ValidateFunc: validation.StringInSlice([]string{"USER", "GROUP"}, false),
+				Description:  "USER for a direct assignment, or GROUP to leave the user's access and profile to a group assigned to the app",
+			},
+			"convert_to_group_on_destroy": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "On destroy, hand the assignment back to the user's group rather than removing the user from the app",
+			},
+			"sync_state": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The state of provisioning the user to the app",
+			},
+			"last_sync": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"user_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				Description: "The user's name in the app, which defaults to the app's user name template",
 			},
 		},
 	}
 }
 
-func resourceAppUserAttachmentCreate(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
-	client := config.Okta
+// Group assignments take their profile from the group, so none of it can be
+// set on them. The roles of direct assignments are checked against the app,
+// so a typo fails the plan rather than the user's next login.
+func resourceAppUserAttachmentCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if err := previewAppUserReplacement(d, m); err != nil {
+		return err
+	}
 
+	if d.Get("scope").(string) == "GROUP" {
+		if d.Get("role").(string) != "" || len(d.Get("saml_roles").([]interface{})) > 0 || len(d.Get("profile").(map[string]interface{})) > 0 {
+			return fmt.Errorf("role, saml_roles and profile can't be set when scope is GROUP, they come from the group")
+		}
+		return nil
+	}
+
+	config, ok := m.(*Config)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"app_id", "role", "saml_roles"} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
+
+	if d.Id() != "" && !d.HasChange("role") && !d.HasChange("saml_roles") {
+		return nil
+	}
+
+	roles := map[string]string{}
+	if role := d.Get("role").(string); role != "" {
+		roles["role"] = role
+	}
+	for i, role := range d.Get("saml_roles").([]interface{}) {
+		roles[fmt.Sprintf("saml_roles.%d", i)] = role.(string)
+	}
+
+	if len(roles) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DiffTimeout)
+	defer cancel()
+	app_id := d.Get("app_id").(string)
+
+	app, err := config.Okta.GetApplication(ctx, app_id)
+	if err != nil || app == nil {
+		return diffTimeoutError(ctx, err)
+	}
+
+	discovered, err := config.Okta.GetAppRoles(ctx, app_id)
+	if err != nil {
+		return diffTimeoutError(ctx, err)
+	}
+
+	keys := make([]string, 0, len(roles))
+	for key := range roles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	problems := []string{}
+	for _, key := range keys {
+		if err := validateAppRole(app, discovered, roles[key]); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s", key, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("Invalid roles for app %s:\n  - %s", app_id, strings.Join(problems, "\n  - "))
+	}
+
+	return nil
+}
+
+// validateAppUserProfile checks each profile value is JSON. The role and SAML
+// roles have attributes of their own, so they can't be set in the profile.
+func validateAppUserProfile(v interface{}, k string) (ws []string, errors []error) {
+	for name, value := range v.(map[string]interface{}) {
+		if name == "role" || name == "samlRoles" {
+			errors = append(errors, fmt.Errorf("%s: %s is set with the role and saml_roles attributes", k, name))
+			continue
+		}
+
+		if !json.Valid([]byte(value.(string))) {
+			errors = append(errors, fmt.Errorf("%s: %s must be JSON encoded, such as with jsonencode()", k, name))
+		}
+	}
+	return
+}
+
+// suppressEquivalentJSON ignores differences in the formatting of JSON
+// values.
+func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
+	var oldValue, newValue interface{}
+	if json.Unmarshal([]byte(old), &oldValue) != nil || json.Unmarshal([]byte(new), &newValue) != nil {
+		return false
+	}
+	return reflect.DeepEqual(oldValue, newValue)
+}
+
+// appUserAssignment returns the assignment of the user to the app from the
+// resource configuration. Profile attributes removed from the configuration
+// are cleared, and the others are checked against the app's user schema.
+func appUserAssignment(ctx context.Context, client api.OktaAPI, d *schema.ResourceData, userID string) (api.OktaAppUserAssignment, error) {
 	app_id := d.Get("app_id").(string)
-	role := d.Get("role").(string)
-	user := d.Get("user").(string)
-	domain := d.Get("domain").(string)
 	saml_roles := d.Get("saml_roles").([]interface{})
 	roles := make([]string, len(saml_roles))
 	for i, value := range saml_roles {
 		roles[i] = value.(string)
 	}
 
-	user_id, err := client.GetUserIDByEmail(user, domain)
+	// Not every app has roles, they are only sent when set now or before.
+	attributes := map[string]interface{}{}
+	if d.Get("role").(string) != "" || d.HasChange("role") {
+		attributes["role"] = d.Get("role").(string)
+	}
+	if len(roles) > 0 || d.HasChange("saml_roles") {
+		attributes["samlRoles"] = roles
+	}
+
+	assignment, err := api.NewAppUserAssignment(userID, attributes)
 	if err != nil {
-		return err
+		return assignment, err
+	}
+
+	if user_name := d.Get("user_name").(string); user_name != "" {
+		assignment.Credentials = &api.OktaAppUserCredentials{UserName: user_name}
+	}
+
+	old, _ := d.GetChange("profile")
+	profile := map[string]json.RawMessage{}
+	for name, value := range d.Get("profile").(map[string]interface{}) {
+		profile[name] = json.RawMessage(value.(string))
+	}
+
+	if len(profile) > 0 {
+		appSchema, err := client.GetAppUserSchema(ctx, app_id)
+		if err != nil {
+			return assignment, err
+		}
+
+		if appSchema == nil {
+			return assignment, fmt.Errorf("Could not find the application: %s", app_id)
+		}
+
+		if err := appSchema.ValidateProfile(profile); err != nil {
+			return assignment, err
+		}
 	}
 
-	_, err = client.AddAppMember(app_id, user_id, role, roles)
+	for name := range old.(map[string]interface{}) {
+		if _, ok := profile[name]; !ok {
+			profile[name] = json.RawMessage("null")
+		}
+	}
+
+	for name, value := range profile {
+		assignment.Profile[name] = value
+	}
+
+	return assignment, nil
+}
+
+func resourceAppUserAttachmentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	user := d.Get("user").(string)
+	domain := d.Get("domain").(string)
+
+	user_id, err := client.GetUserIDByEmail(ctx, user, domain)
 	if err != nil {
 		return err
 	}
 
+	if err := assignAppUser(ctx, client, d, user_id); err != nil {
+		return err
+	}
+
 	d.SetId(user_id)
 
-	return resourceAppUserAttachmentRead(d, m)
+	return resourceAppUserAttachmentRead(ctx, d, m)
 }
 
-func resourceAppUserAttachmentUpdate(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppUserAttachmentUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 
+	if err := assignAppUser(ctx, client, d, d.Id()); err != nil {
+		return err
+	}
+
+	return resourceAppUserAttachmentRead(ctx, d, m)
+}
+
+// assignAppUser assigns the user to the app directly, or hands the
+// assignment to the user's group.
+func assignAppUser(ctx context.Context, client api.OktaAPI, d *schema.ResourceData, userID string) error {
 	app_id := d.Get("app_id").(string)
-	role := d.Get("role").(string)
-	saml_roles := d.Get("saml_roles").([]interface{})
-	roles := make([]string, len(saml_roles))
-	for i, value := range saml_roles {
-		roles[i] = value.(string)
+
+	if d.Get("scope").(string) == "GROUP" {
+		return convertToGroupAssignment(ctx, client, app_id, userID)
 	}
 
-	_, err := client.AddAppMember(app_id, d.Id(), role, roles)
+	assignment, err := appUserAssignment(ctx, client, d, userID)
 	if err != nil {
 		return err
 	}
 
-	return resourceAppUserAttachmentRead(d, m)
+	_, err = client.AssignAppMember(ctx, app_id, assignment)
+	if err != nil {
+		return err
+	}
+
+	// A direct assignment takes precedence over the user's groups, so their
+	// profiles no longer apply to the user.
+	groups, err := client.ListAppMemberGroups(ctx, app_id, userID)
+	if err != nil {
+		log.Printf("[WARN] Could not check the groups user (%s) is assigned to app (%s) through: %s", userID, app_id, err)
+		return nil
+	}
+
+	for _, group := range groups {
+		log.Printf("[WARN] User (%s) in app (%s) is also assigned through group %q, whose profile the direct assignment overrides", userID, app_id, group.Profile.Name)
+	}
+
+	return nil
 }
 
-func resourceAppUserAttachmentRead(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func convertToGroupAssignment(ctx context.Context, client api.OktaAPI, appID string, userID string) error {
+	_, err := client.SetAppMemberScope(ctx, appID, userID, "GROUP")
+	if err != nil {
+		return fmt.Errorf("Could not hand user %s in app %s to a group, they must be in a group assigned to the app: %s", userID, appID, err)
+	}
+
+	return nil
+}
+
+func resourceAppUserAttachmentRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 
-	member, err := client.GetAppMember(d.Get("app_id").(string), d.Id())
-	if err != nil || member == nil {
+	member, err := client.GetAppMember(ctx, d.Get("app_id").(string), d.Id())
+	if err != nil {
+		return err
+	}
+
+	if member == nil {
 		log.Printf("[WARN] User (%s) in app (%s) not found, removing from state", d.Id(), d.Get("app_id").(string))
 		d.SetId("")
 		return nil
@@ -107,23 +378,228 @@ func resourceAppUserAttachmentRead(d *schema.ResourceData, m interface{}) error
 
 	log.Printf("[INFO] App %s user (%s) discovered", d.Get("app_id").(string), d.Id())
 
+	// An assignment made here becomes group inherited when the user is also
+	// assigned through a group and the direct assignment is removed outside
+	// of Terraform, or Okta converts it. The next apply makes it direct again.
+	if member.Scope == "GROUP" && d.Get("scope").(string) != "GROUP" {
+		group := ""
+		if member.Links.Group != nil {
+			group = member.Links.Group.Name
+		}
+		log.Printf("[WARN] User (%s) in app (%s) is now assigned through group %q rather than directly", d.Id(), d.Get("app_id").(string), group)
+	}
+
 	d.Set("status", member.Status)
-	d.Set("email", member.Profile.Email)
-	d.Set("display_name", member.Profile.DisplayName)
+	d.Set("scope", member.Scope)
+	d.Set("sync_state", member.SyncState)
+	d.Set("user_name", member.Credentials.UserName)
+
+	lastSync := ""
+	if member.LastSync != nil {
+		lastSync = member.LastSync.UTC().Format(time.RFC3339)
+	}
+	d.Set("last_sync", lastSync)
+
+	// The profile of a group assignment is the group's.
+	if member.Scope == "GROUP" {
+		d.Set("role", "")
+		d.Set("saml_roles", []string{})
+		d.Set("profile", map[string]interface{}{})
+		return nil
+	}
+
 	d.Set("role", member.Profile.Role)
 	d.Set("saml_roles", member.Profile.SamlRoles)
 
+	// Only the attributes in the configuration are read back, the rest of
+	// the profile is left to Okta and the app's defaults. Values equal to
+	// the configured ones keep its formatting.
+	attributes, err := member.Profile.Attributes()
+	if err != nil {
+		return err
+	}
+
+	profile := map[string]interface{}{}
+	for name, configured := range d.Get("profile").(map[string]interface{}) {
+		value, ok := attributes[name]
+		if !ok {
+			continue
+		}
+
+		if suppressEquivalentJSON(name, configured.(string), string(value), d) {
+			profile[name] = configured
+		} else {
+			profile[name] = string(value)
+		}
+	}
+
+	if err := d.Set("profile", profile); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func resourceAppUserAttachmentDelete(d *schema.ResourceData, m interface{}) error {
-	config := m.(Config)
+func resourceAppUserAttachmentDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
 	client := config.Okta
 
-	err := client.RemoveAppMember(d.Get("app_id").(string), d.Id())
+	app_id := d.Get("app_id").(string)
+
+	app, err := client.GetApplication(ctx, app_id)
+	if err != nil {
+		return err
+	}
+
+	steps := appUserDestroySteps(d, app)
+	if d.Get("destroy_dry_run").(bool) {
+		log.Printf("[WARN] User (%s) was left in app (%s) as destroy_dry_run is set. Destroying the attachment would do the following:\n  - %s", d.Id(), app_id, strings.Join(steps, "\n  - "))
+		return nil
+	}
+
+	for _, step := range steps {
+		log.Printf("[INFO] %s", step)
+	}
+
+	switch {
+	case app == nil || d.Get("scope").(string) == "GROUP":
+		return nil
+	case d.Get("convert_to_group_on_destroy").(bool):
+		return convertToGroupAssignment(ctx, client, app_id, d.Id())
+	case d.Get("retain_downstream").(bool) && app.DeprovisionsUsers():
+		return clearAppUserAssignment(ctx, client, d)
+	}
+
+	err = client.RemoveAppMember(ctx, app_id, d.Id(), d.Get("send_email").(bool))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// warnDestroyDryRun warns on every plan while destroy_dry_run is set, as
+// destroying the attachment then leaves the user assigned, including when it
+// is replaced because the app or user changes.
+func warnDestroyDryRun(v interface{}, k string) (ws []string, errors []error) {
+	if v.(bool) {
+		ws = append(ws, fmt.Sprintf("%s is set, so destroying or replacing this attachment leaves the user assigned and only logs what it would do", k))
+	}
+	return
+}
+
+// previewAppUserReplacement logs what destroying the attachment a planned
+// replacement leaves in place would do, while destroy_dry_run is set.
+func previewAppUserReplacement(d *schema.ResourceDiff, m interface{}) error {
+	dryRun, _ := d.GetChange("destroy_dry_run")
+	if d.Id() == "" || !dryRun.(bool) || !(d.HasChange("app_id") || d.HasChange("user") || d.HasChange("domain")) {
+		return nil
+	}
+
+	config, ok := m.(*Config)
+	if !ok {
+		return nil
+	}
+
+	replaced := replacedAppUser{d}
+	app_id := replaced.Get("app_id").(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), DiffTimeout)
+	defer cancel()
+
+	app, err := config.Okta.GetApplication(ctx, app_id)
+	if err != nil {
+		return diffTimeoutError(ctx, err)
+	}
+
+	steps := appUserDestroySteps(replaced, app)
+	log.Printf("[WARN] User (%s) will be left in app (%s) when the attachment is replaced, as destroy_dry_run is set. Destroying it would do the following:\n  - %s", d.Id(), app_id, strings.Join(steps, "\n  - "))
+	return nil
+}
+
+// appUserAttachment is the attachment appUserDestroySteps describes, either
+// its state or the one a planned replacement destroys.
+type appUserAttachment interface {
+	Get(key string) interface{}
+	Id() string
+}
+
+// replacedAppUser reads the attachment a planned replacement destroys.
+type replacedAppUser struct {
+	d *schema.ResourceDiff
+}
+
+func (r replacedAppUser) Get(key string) interface{} {
+	old, _ := r.d.GetChange(key)
+	return old
+}
+
+func (r replacedAppUser) Id() string {
+	return r.d.Id()
+}
+
+// appUserDestroySteps describes what destroying the attachment does, in Okta
+// and in the app downstream.
+func appUserDestroySteps(d appUserAttachment, app *api.OktaApplication) []string {
+	user := d.Get("user").(string)
+	if user == "" {
+		user = d.Id()
+	}
+
+	if app == nil {
+		return []string{fmt.Sprintf("App %s no longer exists, there is nothing to remove %s from", d.Get("app_id").(string), user)}
+	}
+
+	switch {
+	case d.Get("scope").(string) == "GROUP":
+		return []string{fmt.Sprintf("%s is assigned to %s through a group, the assignment is left to it", user, app.Label)}
+	case d.Get("convert_to_group_on_destroy").(bool):
+		return []string{fmt.Sprintf("%s is handed to their group in %s and keeps access through it", user, app.Label)}
+	case d.Get("retain_downstream").(bool) && app.DeprovisionsUsers():
+		return []string{
+			fmt.Sprintf("%s stays assigned to %s with their role, SAML roles and profile cleared", user, app.Label),
+			fmt.Sprintf("Their account in %s is kept", app.Label),
+		}
+	}
+
+	steps := []string{fmt.Sprintf("%s is unassigned from %s", user, app.Label)}
+	if app.DeprovisionsUsers() {
+		steps = append(steps, fmt.Sprintf("Their account in %s is deactivated", app.Label))
+	} else {
+		steps = append(steps, fmt.Sprintf("Their account in %s is left as it is", app.Label))
+	}
+
+	if d.Get("send_email").(bool) {
+		steps = append(steps, fmt.Sprintf("%s is emailed about the removal", user))
+	}
+
+	return steps
+}
+
+// clearAppUserAssignment takes away the role, SAML roles and profile the
+// attachment set, leaving the user assigned so the app doesn't deprovision
+// them.
+func clearAppUserAssignment(ctx context.Context, client api.OktaAPI, d *schema.ResourceData) error {
+	app_id := d.Get("app_id").(string)
+
+	attributes := map[string]interface{}{
+		"role":      nil,
+		"samlRoles": nil,
+	}
+	for name := range d.Get("profile").(map[string]interface{}) {
+		attributes[name] = nil
+	}
+
+	assignment, err := api.NewAppUserAssignment(d.Id(), attributes)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.AssignAppMember(ctx, app_id, assignment)
 	if err != nil {
 		return err
 	}
 
+	log.Printf("[WARN] User (%s) was left in app (%s) to keep their account downstream, unassign them once it can be deactivated", d.Id(), app_id)
 	return nil
 }
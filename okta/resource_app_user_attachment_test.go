@@ -0,0 +1,465 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/config"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResourceAppUserAttachment_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id":     app.ID,
+		"user":       "jdoe@example.com",
+		"role":       "ReadOnly",
+		"saml_roles": []interface{}{"ReadOnly"},
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != userID {
+		t.Fatalf("expected ID %s, got %s", userID, d.Id())
+	}
+
+	member := okta.Members[app.ID][userID]
+	if member == nil || member.Profile.Role != "ReadOnly" {
+		t.Fatalf("expected the user to be assigned with role ReadOnly, got %+v", member)
+	}
+
+	d.Set("saml_roles", []interface{}{"ReadOnly", "Admin"})
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	roles := okta.Members[app.ID][userID].Profile.SamlRoles
+	if !reflect.DeepEqual(roles, []string{"ReadOnly", "Admin"}) {
+		t.Fatalf("expected the SAML roles to be updated, got %v", roles)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Members[app.ID][userID]; ok {
+		t.Fatalf("expected the user to be unassigned")
+	}
+}
+
+func TestResourceAppUserAttachment_readRemovesMissingMember(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id": app.ID,
+	})
+	d.SetId(userID)
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("expected the attachment to be removed from state")
+	}
+}
+
+func TestResourceAppUserAttachment_profile(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+
+	appSchema := &api.OktaAppUserSchema{}
+	appSchema.Definitions.Base.Properties = map[string]api.OktaSchemaProperty{
+		"role":            {Type: "string"},
+		"samlRoles":       {Type: "array", Items: &api.OktaSchemaProperty{Type: "string"}},
+		"sessionDuration": {Type: "integer"},
+		"costCentre":      {Type: "string"},
+	}
+	okta.Schemas[app.ID] = appSchema
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id":     app.ID,
+		"user":       "jdoe@example.com",
+		"role":       "ReadOnly",
+		"saml_roles": []interface{}{"ReadOnly"},
+		"user_name":  "jane.doe",
+		"profile": map[string]interface{}{
+			"sessionDuration": "3600",
+			"costCentre":      `"R&D"`,
+		},
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	member := okta.Members[app.ID][userID]
+	if member.Credentials.UserName != "jane.doe" || string(member.Profile.Unknown["sessionDuration"]) != "3600" {
+		t.Fatalf("expected the user name and profile to be set, got %+v", member)
+	}
+
+	if d.Get("profile.costCentre").(string) != `"R&D"` || d.Get("user_name").(string) != "jane.doe" {
+		t.Fatalf("expected the profile to be read back, got %v", d.Get("profile"))
+	}
+
+	d = r.Data(d.State())
+	d.Set("profile", map[string]interface{}{"sessionDuration": "7200"})
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	member = okta.Members[app.ID][userID]
+	if _, ok := member.Profile.Unknown["costCentre"]; ok || string(member.Profile.Unknown["sessionDuration"]) != "7200" {
+		t.Fatalf("expected the removed attribute to be cleared, got %s", member.Profile.Unknown)
+	}
+
+	d.Set("profile", map[string]interface{}{"sessionDuration": `"long"`})
+	if err := r.Update(d, config); err == nil || !strings.Contains(err.Error(), "must be an integer") {
+		t.Fatalf("expected the profile to be checked against the app's user schema, got %v", err)
+	}
+}
+
+func TestValidateAppUserProfile(t *testing.T) {
+	_, errors := validateAppUserProfile(map[string]interface{}{"sessionDuration": "3600", "costCentre": `"R&D"`}, "profile")
+	if len(errors) != 0 {
+		t.Fatalf("unexpected errors %v", errors)
+	}
+
+	_, errors = validateAppUserProfile(map[string]interface{}{"samlRoles": `["Admin"]`, "costCentre": "R&D"}, "profile")
+	if len(errors) != 2 {
+		t.Fatalf("expected the SAML roles and the value that isn't JSON to be rejected, got %v", errors)
+	}
+}
+
+func TestResourceAppUserAttachment_readKeepsStateOnError(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+	okta.Errors["GetAppMember"] = fmt.Errorf("Response not successful: Received status code 500")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id": app.ID,
+	})
+	d.SetId(userID)
+
+	if err := r.Read(d, config); err == nil {
+		t.Fatalf("expected the error to be returned")
+	}
+
+	if d.Id() != userID {
+		t.Fatalf("expected the attachment to be kept in state")
+	}
+}
+
+func TestResourceAppUserAttachment_readAssignmentState(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+	okta.AddAppMember(context.Background(), app.ID, userID, "ReadOnly", []string{"ReadOnly"})
+
+	lastSync := time.Date(2020, 3, 2, 18, 30, 0, 0, time.UTC)
+	member := okta.Members[app.ID][userID]
+	member.Scope = "GROUP"
+	member.SyncState = "SYNCHRONIZED"
+	member.LastSync = &lastSync
+	member.Links.Group = &api.OktaLink{Name: "aws_123456789012_ReadOnly"}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id": app.ID,
+	})
+	d.SetId(userID)
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Get("scope").(string) != "GROUP" || d.Get("sync_state").(string) != "SYNCHRONIZED" || d.Get("last_sync").(string) != "2020-03-02T18:30:00Z" {
+		t.Fatalf("unexpected assignment state %q, %q, %q", d.Get("scope"), d.Get("sync_state"), d.Get("last_sync"))
+	}
+}
+
+func TestResourceAppUserAttachment_groupScope(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+	okta.AddGroup("aws_123456789012_ReadOnly", []string{userID}, app.ID)
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id":     app.ID,
+		"user":       "jdoe@example.com",
+		"role":       "Admin",
+		"saml_roles": []interface{}{"Admin"},
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Members[app.ID][userID].Scope != "USER" || d.Get("scope").(string) != "USER" {
+		t.Fatalf("expected a direct assignment, got %q", okta.Members[app.ID][userID].Scope)
+	}
+
+	d = r.Data(d.State())
+	d.Set("scope", "GROUP")
+	d.Set("role", "")
+	d.Set("saml_roles", []interface{}{})
+	if err := r.Update(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.Members[app.ID][userID].Scope != "GROUP" || d.Get("role").(string) != "" {
+		t.Fatalf("expected the assignment to be handed to the group, got %q", okta.Members[app.ID][userID].Scope)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Members[app.ID][userID]; !ok {
+		t.Fatalf("expected the group assignment to be left in place")
+	}
+}
+
+func TestResourceAppUserAttachment_convertToGroupOnDestroy(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+	otherID := okta.AddUser("jsmith@example.com", "jsmith@example.com")
+
+	for _, user := range []string{"jdoe@example.com", "jsmith@example.com"} {
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"app_id":                      app.ID,
+			"user":                        user,
+			"role":                        "Admin",
+			"saml_roles":                  []interface{}{"Admin"},
+			"convert_to_group_on_destroy": true,
+		})
+
+		if err := r.Create(d, config); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	okta.AddGroup("aws_123456789012_ReadOnly", []string{userID}, app.ID)
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id":                      app.ID,
+		"convert_to_group_on_destroy": true,
+	})
+	d.SetId(userID)
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if member, ok := okta.Members[app.ID][userID]; !ok || member.Scope != "GROUP" {
+		t.Fatalf("expected the user to keep access through the group")
+	}
+
+	d.SetId(otherID)
+	if err := r.Delete(d, config); err == nil || !strings.Contains(err.Error(), "must be in a group assigned to the app") {
+		t.Fatalf("expected an error for a user in no group, got %v", err)
+	}
+}
+
+func TestResourceAppUserAttachment_groupScopeRejectsProfile(t *testing.T) {
+	r := resourceAppUserAttachment()
+
+	raw, err := config.NewRawConfig(map[string]interface{}{
+		"app_id":     "0oa1",
+		"user":       "jdoe@example.com",
+		"scope":      "GROUP",
+		"saml_roles": []interface{}{"Admin"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := r.Diff(nil, terraform.NewResourceConfig(raw), nil); err == nil || !strings.Contains(err.Error(), "scope is GROUP") {
+		t.Fatalf("expected SAML roles to be rejected on a group assignment, got %v", err)
+	}
+}
+
+func TestResourceAppUserAttachment_destroyOptions(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	okta.Applications[app.ID].Features = []string{"PUSH_NEW_USERS", "PUSH_USER_DEACTIVATION"}
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"app_id":          app.ID,
+		"user":            "jdoe@example.com",
+		"role":            "Admin",
+		"saml_roles":      []interface{}{"Admin"},
+		"send_email":      true,
+		"destroy_dry_run": true,
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	warnings, errs := r.Validate(testResourceConfig(t, map[string]interface{}{
+		"app_id":          app.ID,
+		"user":            "jdoe@example.com",
+		"destroy_dry_run": true,
+	}))
+	if len(errs) > 0 || len(warnings) != 1 || !strings.Contains(warnings[0], "destroying or replacing this attachment leaves the user assigned") {
+		t.Fatalf("expected a warning that destroying leaves the user assigned, got %v %v", warnings, errs)
+	}
+
+	steps := appUserDestroySteps(d, okta.Applications[app.ID])
+	if !reflect.DeepEqual(steps, []string{
+		"jdoe@example.com is unassigned from ACME-AwsAccount",
+		"Their account in ACME-AwsAccount is deactivated",
+		"jdoe@example.com is emailed about the removal",
+	}) {
+		t.Fatalf("expected a preview of the deprovisioning, got %v", steps)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Members[app.ID][userID]; !ok || len(okta.Emailed) > 0 {
+		t.Fatalf("expected a dry run to leave the user assigned")
+	}
+
+	d.Set("destroy_dry_run", false)
+	d.Set("retain_downstream", true)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	member, ok := okta.Members[app.ID][userID]
+	if !ok || member.Profile.Role != "" || len(member.Profile.SamlRoles) != 0 {
+		t.Fatalf("expected the user to stay assigned without roles, got %+v", member)
+	}
+
+	d.Set("retain_downstream", false)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Members[app.ID][userID]; ok || !reflect.DeepEqual(okta.Emailed, []string{userID}) {
+		t.Fatalf("expected the user to be unassigned and emailed, got %v", okta.Emailed)
+	}
+}
+
+func TestResourceAppUserAttachment_destroyDryRunReplacement(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	other, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsOtherAccount", "")
+	userID := okta.AddUser("jdoe@example.com", "jdoe@example.com")
+
+	values := map[string]interface{}{
+		"app_id":          app.ID,
+		"user":            "jdoe@example.com",
+		"destroy_dry_run": true,
+	}
+	diff, err := r.Diff(nil, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	state, err := r.Apply(nil, diff, config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Moving the attachment to another app replaces it. With
+	// create_before_destroy the new one is created before the old one is
+	// destroyed, which the dry run lets through.
+	values["app_id"] = other.ID
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.RequiresNew() {
+		t.Fatalf("expected the attachment to be replaced, got %#v", diff)
+	}
+
+	create, err := r.Diff(nil, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := r.Apply(nil, create, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := r.Apply(state, &terraform.InstanceDiff{Destroy: true}, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Members[other.ID][userID]; !ok {
+		t.Fatalf("expected the user to be assigned to the new app")
+	}
+
+	if _, ok := okta.Members[app.ID][userID]; !ok {
+		t.Fatalf("expected the dry run to leave the user in the old app")
+	}
+}
+
+func TestResourceAppUserAttachment_validatesRoles(t *testing.T) {
+	providerConfig, okta, _ := testFakeConfig()
+	r := resourceAppUserAttachment()
+
+	app, _ := okta.CreateAwsApplication(context.Background(), "ACME-AwsAccount", "")
+	okta.Roles[app.ID] = []string{"Admin", "ReadOnly"}
+
+	resourceConfig := func(roles ...interface{}) *terraform.ResourceConfig {
+		raw, err := config.NewRawConfig(map[string]interface{}{
+			"app_id":     app.ID,
+			"user":       "jdoe@example.com",
+			"role":       "ReadOnly",
+			"saml_roles": roles,
+		})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return terraform.NewResourceConfig(raw)
+	}
+
+	if _, err := r.Diff(nil, resourceConfig("ReadOnly", "Admin"), providerConfig); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	_, err := r.Diff(nil, resourceConfig("ReadOnly", "Admn"), providerConfig)
+	if err == nil || !strings.Contains(err.Error(), `saml_roles.1: "Admn" is not a role discovered in app ACME-AwsAccount`) {
+		t.Fatalf("expected the misspelt role to be named, got %v", err)
+	}
+}
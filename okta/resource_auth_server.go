@@ -0,0 +1,185 @@
+package okta
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceAuthServer() *schema.Resource {
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAuthServerCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAuthServerRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAuthServerUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAuthServerDelete),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"audiences": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The audiences of the access tokens the server issues, usually the URLs of the APIs",
+			},
+			"issuer_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "ORG_URL",
+				ValidateFunc: validation.StringInSlice([]string{"ORG_URL", "CUSTOM_URL", "DYNAMIC"}, false),
+				Description:  "Whether the issuer is the Okta org URL, the org's custom domain, or the domain the request was made to",
+			},
+			"status": statusSchema(),
+			"credentials_rotation_mode": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "AUTO",
+				ValidateFunc: validation.StringInSlice([]string{"AUTO", "MANUAL"}, false),
+				Description:  "AUTO for Okta to rotate the signing keys, or MANUAL to only rotate them on request",
+			},
+			"issuer": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"kid": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The ID of the key tokens are currently signed with",
+			},
+			"credentials_last_rotated": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"credentials_next_rotation": &schema.Schema{
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func authServer(d *schema.ResourceData) api.OktaAuthServer {
+	return api.OktaAuthServer{
+		ID:          d.Id(),
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Audiences:   stringSet(d, "audiences"),
+		IssuerMode:  d.Get("issuer_mode").(string),
+		Credentials: &api.OktaAuthServerCredentials{
+			Signing: api.OktaAuthServerSigning{
+				RotationMode: d.Get("credentials_rotation_mode").(string),
+			},
+		},
+	}
+}
+
+func resourceAuthServerCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	server, err := client.CreateAuthServer(ctx, authServer(d))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(server.ID)
+
+	// Servers are created active.
+	if d.Get("status").(string) == "INACTIVE" {
+		if err := client.SetAuthServerActive(ctx, server.ID, false); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerRead(ctx, d, m)
+}
+
+func resourceAuthServerRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	server, err := client.GetAuthServer(ctx, d.Id())
+	if err != nil {
+		return err
+	}
+
+	if server == nil {
+		log.Printf("[WARN] Okta authorization server not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", server.Name)
+	d.Set("description", server.Description)
+	d.Set("audiences", server.Audiences)
+	d.Set("issuer_mode", server.IssuerMode)
+	d.Set("status", server.Status)
+	d.Set("issuer", server.Issuer)
+
+	if server.Credentials != nil {
+		signing := server.Credentials.Signing
+		d.Set("credentials_rotation_mode", signing.RotationMode)
+		d.Set("kid", signing.KeyID)
+
+		if signing.LastRotated != nil {
+			d.Set("credentials_last_rotated", signing.LastRotated.Format(time.RFC3339))
+		}
+		if signing.NextRotation != nil {
+			d.Set("credentials_next_rotation", signing.NextRotation.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+func resourceAuthServerUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	if _, err := client.UpdateAuthServer(ctx, authServer(d)); err != nil {
+		return err
+	}
+
+	if d.HasChange("status") {
+		if err := client.SetAuthServerActive(ctx, d.Id(), d.Get("status").(string) == "ACTIVE"); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerRead(ctx, d, m)
+}
+
+// Okta only deletes inactive servers.
+func resourceAuthServerDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	err := client.SetAuthServerActive(ctx, d.Id(), false)
+	if err != nil {
+		return err
+	}
+
+	err = client.DeleteAuthServer(ctx, d.Id())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceAuthServerClaim() *schema.Resource {
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAuthServerClaimCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAuthServerClaimRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAuthServerClaimUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAuthServerClaimDelete),
+
+		CustomizeDiff: resourceAuthServerClaimCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"auth_server_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"claim_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Required:     true,
+				ForceNew:     true,
+				ValidateFunc: validation.StringInSlice([]string{"RESOURCE", "IDENTITY"}, false),
+				Description:  "RESOURCE to add the claim to access tokens, or IDENTITY to add it to ID tokens",
+			},
+			"value_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "EXPRESSION",
+				ValidateFunc: validation.StringInSlice([]string{"EXPRESSION", "GROUPS"}, false),
+			},
+			"value": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "An Okta expression, or the group filter when value_type is GROUPS",
+			},
+			"group_filter_type": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringInSlice([]string{"STARTS_WITH", "EQUALS", "CONTAINS", "REGEX"}, false),
+				Description:  "How the groups are matched against the value, when value_type is GROUPS",
+			},
+			"always_include_in_token": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Include the claim whatever the scopes requested, rather than only when they are fetched from the userinfo endpoint",
+			},
+			"scopes": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "Only include the claim when one of the scopes is granted",
+			},
+			"status": statusSchema(),
+		},
+	}
+}
+
+func resourceAuthServerClaimCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("value_type") || !d.NewValueKnown("group_filter_type") {
+		return nil
+	}
+
+	groups := d.Get("value_type").(string) == "GROUPS"
+	filtered := d.Get("group_filter_type").(string) != ""
+
+	if groups && !filtered {
+		return fmt.Errorf("group_filter_type must be set when value_type is GROUPS")
+	}
+	if !groups && filtered {
+		return fmt.Errorf("group_filter_type can only be set when value_type is GROUPS")
+	}
+
+	return nil
+}
+
+func authServerClaim(d *schema.ResourceData) api.OktaAuthServerClaim {
+	claim := api.OktaAuthServerClaim{
+		ID:                   d.Id(),
+		Name:                 d.Get("name").(string),
+		Status:               d.Get("status").(string),
+		ClaimType:            d.Get("claim_type").(string),
+		ValueType:            d.Get("value_type").(string),
+		Value:                d.Get("value").(string),
+		GroupFilterType:      d.Get("group_filter_type").(string),
+		AlwaysIncludeInToken: d.Get("always_include_in_token").(bool),
+	}
+
+	if scopes := stringSet(d, "scopes"); len(scopes) > 0 {
+		claim.Conditions = &api.OktaAuthServerClaimConditions{Scopes: scopes}
+	}
+
+	return claim
+}
+
+func resourceAuthServerClaimCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	claim, err := client.CreateAuthServerClaim(ctx, d.Get("auth_server_id").(string), authServerClaim(d))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(claim.ID)
+	return resourceAuthServerClaimRead(ctx, d, m)
+}
+
+func resourceAuthServerClaimRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	claim, err := client.GetAuthServerClaim(ctx, d.Get("auth_server_id").(string), d.Id())
+	if err != nil {
+		return err
+	}
+
+	if claim == nil {
+		log.Printf("[WARN] Okta authorization server claim not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", claim.Name)
+	d.Set("status", claim.Status)
+	d.Set("claim_type", claim.ClaimType)
+	d.Set("value_type", claim.ValueType)
+	d.Set("value", claim.Value)
+	d.Set("group_filter_type", claim.GroupFilterType)
+	d.Set("always_include_in_token", claim.AlwaysIncludeInToken)
+
+	scopes := []string{}
+	if claim.Conditions != nil {
+		scopes = claim.Conditions.Scopes
+	}
+	d.Set("scopes", scopes)
+
+	return nil
+}
+
+func resourceAuthServerClaimUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	if _, err := client.UpdateAuthServerClaim(ctx, d.Get("auth_server_id").(string), authServerClaim(d)); err != nil {
+		return err
+	}
+
+	return resourceAuthServerClaimRead(ctx, d, m)
+}
+
+func resourceAuthServerClaimDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	return client.DeleteAuthServerClaim(ctx, d.Get("auth_server_id").(string), d.Id())
+}
@@ -0,0 +1,84 @@
+package okta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourceAuthServerClaim_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	serverID := testAuthServer(t, okta)
+	r := resourceAuthServerClaim()
+
+	values := map[string]interface{}{
+		"auth_server_id":    serverID,
+		"name":              "groups",
+		"claim_type":        "RESOURCE",
+		"value_type":        "GROUPS",
+		"value":             "app_",
+		"group_filter_type": "STARTS_WITH",
+		"scopes":            []interface{}{"groups"},
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	claim := okta.AuthServers[serverID].Claims[d.Id()]
+	if claim == nil || claim.GroupFilterType != "STARTS_WITH" || !claim.AlwaysIncludeInToken {
+		t.Fatalf("expected the claim to be created, got %+v", claim)
+	}
+
+	if claim.Conditions == nil || len(claim.Conditions.Scopes) != 1 || claim.Conditions.Scopes[0] != "groups" {
+		t.Fatalf("expected the claim to be limited to the groups scope, got %+v", claim.Conditions)
+	}
+
+	values["status"] = "INACTIVE"
+	delete(values, "scopes")
+	state := d.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	claim = okta.AuthServers[serverID].Claims[state.ID]
+	if claim.Status != "INACTIVE" || claim.Conditions != nil {
+		t.Fatalf("expected an inactive claim for every scope, got %+v", claim)
+	}
+
+	d = r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.AuthServers[serverID].Claims[state.ID]; ok {
+		t.Fatalf("expected claim %s to be deleted", state.ID)
+	}
+}
+
+func TestResourceAuthServerClaim_groupFilterType(t *testing.T) {
+	config, _, _ := testFakeConfig()
+	r := resourceAuthServerClaim()
+
+	for _, values := range []map[string]interface{}{
+		{"value_type": "GROUPS"},
+		{"value_type": "EXPRESSION", "group_filter_type": "REGEX"},
+	} {
+		values["auth_server_id"] = "aus1"
+		values["name"] = "groups"
+		values["claim_type"] = "IDENTITY"
+		values["value"] = ".*"
+
+		_, err := r.Diff(nil, testResourceConfig(t, values), config)
+		if err == nil || !strings.Contains(err.Error(), "group_filter_type") {
+			t.Fatalf("expected a group_filter_type error for %v, got %v", values, err)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package okta
+
+import (
+	"context"
+	"log"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceAuthServerPolicy() *schema.Resource {
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAuthServerPolicyCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAuthServerPolicyRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAuthServerPolicyUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAuthServerPolicyDelete),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"auth_server_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"priority": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The order the policy is evaluated in, from 1. Policies at or after it are moved down, it is added last when not set",
+			},
+			"status": statusSchema(),
+			"client_whitelist": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The IDs of the clients the policy applies to, or ALL_CLIENTS",
+			},
+		},
+	}
+}
+
+func authServerPolicy(d *schema.ResourceData) api.OktaAuthServerPolicy {
+	return api.OktaAuthServerPolicy{
+		ID:          d.Id(),
+		Type:        "OAUTH_AUTHORIZATION_POLICY",
+		Name:        d.Get("name").(string),
+		Description: d.Get("description").(string),
+		Priority:    d.Get("priority").(int),
+		Conditions: api.OktaAuthServerPolicyConditions{
+			Clients: api.OktaInclude{Include: stringSet(d, "client_whitelist")},
+		},
+	}
+}
+
+func resourceAuthServerPolicyCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	serverID := d.Get("auth_server_id").(string)
+
+	policy, err := client.CreateAuthServerPolicy(ctx, serverID, authServerPolicy(d))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(policy.ID)
+
+	if d.Get("status").(string) == "INACTIVE" {
+		if err := client.SetAuthServerPolicyActive(ctx, serverID, policy.ID, false); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerPolicyRead(ctx, d, m)
+}
+
+func resourceAuthServerPolicyRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	policy, err := client.GetAuthServerPolicy(ctx, d.Get("auth_server_id").(string), d.Id())
+	if err != nil {
+		return err
+	}
+
+	if policy == nil {
+		log.Printf("[WARN] Okta authorization server policy not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", policy.Name)
+	d.Set("description", policy.Description)
+	d.Set("priority", policy.Priority)
+	d.Set("status", policy.Status)
+	d.Set("client_whitelist", policy.Conditions.Clients.Include)
+
+	return nil
+}
+
+func resourceAuthServerPolicyUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	serverID := d.Get("auth_server_id").(string)
+
+	if _, err := client.UpdateAuthServerPolicy(ctx, serverID, authServerPolicy(d)); err != nil {
+		return err
+	}
+
+	if d.HasChange("status") {
+		if err := client.SetAuthServerPolicyActive(ctx, serverID, d.Id(), d.Get("status").(string) == "ACTIVE"); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerPolicyRead(ctx, d, m)
+}
+
+func resourceAuthServerPolicyDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	return client.DeleteAuthServerPolicy(ctx, d.Get("auth_server_id").(string), d.Id())
+}
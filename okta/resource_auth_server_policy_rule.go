@@ -0,0 +1,246 @@
+package okta
+
+import (
+	"context"
+	"log"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// The longest Okta allows a refresh token to live, five years.
+const maxRefreshTokenMinutes = 5 * 365 * 24 * 60
+
+var authServerGrantTypes = []string{
+	"authorization_code",
+	"client_credentials",
+	"implicit",
+	"password",
+	"urn:ietf:params:oauth:grant-type:device_code",
+	"urn:ietf:params:oauth:grant-type:saml2-bearer",
+	"urn:ietf:params:oauth:grant-type:token-exchange",
+}
+
+func resourceAuthServerPolicyRule() *schema.Resource {
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAuthServerPolicyRuleCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAuthServerPolicyRuleRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAuthServerPolicyRuleUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAuthServerPolicyRuleDelete),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"auth_server_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"policy_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"priority": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "The order the rule is evaluated in within the policy, from 1. It is added last when not set",
+			},
+			"status": statusSchema(),
+			"grant_type_whitelist": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString, ValidateFunc: validation.StringInSlice(authServerGrantTypes, false)},
+				Set:         schema.HashString,
+				Description: "The grant types the rule allows",
+			},
+			"scope_whitelist": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The scopes the rule grants, or * for every scope of the server",
+			},
+			"group_whitelist": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Set:         schema.HashString,
+				Description: "The IDs of the groups the rule applies to. It applies to everyone when neither groups nor users are given",
+			},
+			"group_blacklist": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"user_whitelist": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"user_blacklist": &schema.Schema{
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"access_token_lifetime_minutes": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      60,
+				ValidateFunc: validation.IntBetween(5, 1440),
+			},
+			"refresh_token_lifetime_minutes": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntBetween(0, maxRefreshTokenMinutes),
+				Description:  "How long a refresh token lives, 0 for it to never expire",
+			},
+			"refresh_token_window_minutes": &schema.Schema{
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      10080,
+				ValidateFunc: validation.IntBetween(10, maxRefreshTokenMinutes),
+				Description:  "How long a refresh token can go unused before it expires",
+			},
+		},
+	}
+}
+
+func authServerPolicyRule(d *schema.ResourceData) api.OktaAuthServerPolicyRule {
+	people := api.OktaPeopleCondition{
+		Users: api.OktaIncludeExclude{
+			Include: stringSet(d, "user_whitelist"),
+			Exclude: stringSet(d, "user_blacklist"),
+		},
+		Groups: api.OktaIncludeExclude{
+			Include: stringSet(d, "group_whitelist"),
+			Exclude: stringSet(d, "group_blacklist"),
+		},
+	}
+
+	// Okta needs the rule to include someone.
+	if len(people.Users.Include) == 0 && len(people.Groups.Include) == 0 {
+		people.Groups.Include = []string{"EVERYONE"}
+	}
+
+	return api.OktaAuthServerPolicyRule{
+		ID:       d.Id(),
+		Type:     "RESOURCE_ACCESS",
+		Name:     d.Get("name").(string),
+		Priority: d.Get("priority").(int),
+		Conditions: api.OktaAuthServerPolicyRuleConditions{
+			People:     people,
+			GrantTypes: api.OktaInclude{Include: stringSet(d, "grant_type_whitelist")},
+			Scopes:     api.OktaInclude{Include: stringSet(d, "scope_whitelist")},
+		},
+		Actions: api.OktaAuthServerPolicyRuleActions{
+			Token: api.OktaAuthServerTokenActions{
+				AccessTokenLifetimeMinutes:  d.Get("access_token_lifetime_minutes").(int),
+				RefreshTokenLifetimeMinutes: d.Get("refresh_token_lifetime_minutes").(int),
+				RefreshTokenWindowMinutes:   d.Get("refresh_token_window_minutes").(int),
+			},
+		},
+	}
+}
+
+func resourceAuthServerPolicyRuleCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	serverID := d.Get("auth_server_id").(string)
+	policyID := d.Get("policy_id").(string)
+
+	rule, err := client.CreateAuthServerPolicyRule(ctx, serverID, policyID, authServerPolicyRule(d))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(rule.ID)
+
+	if d.Get("status").(string) == "INACTIVE" {
+		if err := client.SetAuthServerPolicyRuleActive(ctx, serverID, policyID, rule.ID, false); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerPolicyRuleRead(ctx, d, m)
+}
+
+func resourceAuthServerPolicyRuleRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	rule, err := client.GetAuthServerPolicyRule(ctx, d.Get("auth_server_id").(string), d.Get("policy_id").(string), d.Id())
+	if err != nil {
+		return err
+	}
+
+	if rule == nil {
+		log.Printf("[WARN] Okta authorization server policy rule not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	people := rule.Conditions.People
+
+	// EVERYONE is only sent in place of groups that weren't configured.
+	groups := people.Groups.Include
+	if len(groups) == 1 && groups[0] == "EVERYONE" && d.Get("group_whitelist").(*schema.Set).Len() == 0 {
+		groups = []string{}
+	}
+
+	d.Set("name", rule.Name)
+	d.Set("priority", rule.Priority)
+	d.Set("status", rule.Status)
+	d.Set("grant_type_whitelist", rule.Conditions.GrantTypes.Include)
+	d.Set("scope_whitelist", rule.Conditions.Scopes.Include)
+	d.Set("group_whitelist", groups)
+	d.Set("group_blacklist", people.Groups.Exclude)
+	d.Set("user_whitelist", people.Users.Include)
+	d.Set("user_blacklist", people.Users.Exclude)
+	d.Set("access_token_lifetime_minutes", rule.Actions.Token.AccessTokenLifetimeMinutes)
+	d.Set("refresh_token_lifetime_minutes", rule.Actions.Token.RefreshTokenLifetimeMinutes)
+	d.Set("refresh_token_window_minutes", rule.Actions.Token.RefreshTokenWindowMinutes)
+
+	return nil
+}
+
+func resourceAuthServerPolicyRuleUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+	serverID := d.Get("auth_server_id").(string)
+	policyID := d.Get("policy_id").(string)
+
+	if _, err := client.UpdateAuthServerPolicyRule(ctx, serverID, policyID, authServerPolicyRule(d)); err != nil {
+		return err
+	}
+
+	if d.HasChange("status") {
+		if err := client.SetAuthServerPolicyRuleActive(ctx, serverID, policyID, d.Id(), d.Get("status").(string) == "ACTIVE"); err != nil {
+			return err
+		}
+	}
+
+	return resourceAuthServerPolicyRuleRead(ctx, d, m)
+}
+
+func resourceAuthServerPolicyRuleDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	return client.DeleteAuthServerPolicyRule(ctx, d.Get("auth_server_id").(string), d.Get("policy_id").(string), d.Id())
+}
@@ -0,0 +1,90 @@
+package okta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourceAuthServerPolicyRule_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	serverID := testAuthServer(t, okta)
+	policy, err := okta.CreateAuthServerPolicy(context.Background(), serverID, api.OktaAuthServerPolicy{
+		Type: "OAUTH_AUTHORIZATION_POLICY",
+		Name: "default",
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	r := resourceAuthServerPolicyRule()
+
+	values := map[string]interface{}{
+		"auth_server_id":       serverID,
+		"policy_id":            policy.ID,
+		"name":                 "services",
+		"grant_type_whitelist": []interface{}{"client_credentials"},
+		"scope_whitelist":      []interface{}{"reports:read"},
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule := okta.AuthServers[serverID].Rules[policy.ID][d.Id()]
+	if rule == nil || rule.Priority != 1 || rule.Actions.Token.AccessTokenLifetimeMinutes != 60 {
+		t.Fatalf("expected the rule to be created, got %+v", rule)
+	}
+
+	if groups := rule.Conditions.People.Groups.Include; len(groups) != 1 || groups[0] != "EVERYONE" {
+		t.Fatalf("expected the rule to apply to everyone, got %v", groups)
+	}
+
+	if d.Get("group_whitelist").(*schema.Set).Len() != 0 {
+		t.Fatalf("expected EVERYONE to be left out of group_whitelist, got %v", d.Get("group_whitelist"))
+	}
+
+	state := d.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for an unchanged rule, got %#v", diff)
+	}
+
+	values["group_whitelist"] = []interface{}{"00g1services"}
+	values["user_blacklist"] = []interface{}{"00u1intern"}
+	values["access_token_lifetime_minutes"] = 15
+	values["status"] = "INACTIVE"
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule = okta.AuthServers[serverID].Rules[policy.ID][state.ID]
+	people := rule.Conditions.People
+	if len(people.Groups.Include) != 1 || people.Groups.Include[0] != "00g1services" || len(people.Users.Exclude) != 1 {
+		t.Fatalf("expected the rule to apply to the group except the user, got %+v", people)
+	}
+
+	if rule.Status != "INACTIVE" || rule.Actions.Token.AccessTokenLifetimeMinutes != 15 {
+		t.Fatalf("expected an inactive rule with 15 minute tokens, got %+v", rule)
+	}
+
+	d = r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.AuthServers[serverID].Rules[policy.ID][state.ID]; ok {
+		t.Fatalf("expected rule %s to be deleted", state.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourceAuthServerPolicy_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	serverID := testAuthServer(t, okta)
+	r := resourceAuthServerPolicy()
+
+	create := func(name string, priority int) *schema.ResourceData {
+		values := map[string]interface{}{
+			"auth_server_id":   serverID,
+			"name":             name,
+			"description":      name + " clients",
+			"client_whitelist": []interface{}{"ALL_CLIENTS"},
+		}
+		if priority > 0 {
+			values["priority"] = priority
+		}
+
+		d := schema.TestResourceDataRaw(t, r.Schema, values)
+		if err := r.Create(d, config); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return d
+	}
+
+	first := create("first", 0)
+	second := create("second", 0)
+	if first.Get("priority").(int) != 1 || second.Get("priority").(int) != 2 {
+		t.Fatalf("expected policies without a priority to be added last, got %d and %d", first.Get("priority"), second.Get("priority"))
+	}
+
+	urgent := create("urgent", 1)
+	policies := okta.AuthServers[serverID].Policies
+	if urgent.Get("priority").(int) != 1 || policies[first.Id()].Priority != 2 || policies[second.Id()].Priority != 3 {
+		t.Fatalf("expected the other policies to be moved down, got %d and %d", policies[first.Id()].Priority, policies[second.Id()].Priority)
+	}
+
+	values := map[string]interface{}{
+		"auth_server_id":   serverID,
+		"name":             "urgent",
+		"description":      "urgent clients",
+		"priority":         1,
+		"status":           "INACTIVE",
+		"client_whitelist": []interface{}{"0oa1client"},
+	}
+
+	state := urgent.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy := policies[state.ID]
+	if policy.Status != "INACTIVE" || len(policy.Conditions.Clients.Include) != 1 || policy.Conditions.Clients.Include[0] != "0oa1client" {
+		t.Fatalf("expected an inactive policy for the client, got %+v", policy)
+	}
+
+	d := r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := policies[state.ID]; ok {
+		t.Fatalf("expected policy %s to be deleted", state.ID)
+	}
+}
@@ -0,0 +1,135 @@
+package okta
+
+import (
+	"context"
+	"log"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+func resourceAuthServerScope() *schema.Resource {
+	return &schema.Resource{
+		Create: withTimeout(schema.TimeoutCreate, resourceAuthServerScopeCreate),
+		Read:   withTimeout(schema.TimeoutRead, resourceAuthServerScopeRead),
+		Update: withTimeout(schema.TimeoutUpdate, resourceAuthServerScopeUpdate),
+		Delete: withTimeout(schema.TimeoutDelete, resourceAuthServerScopeDelete),
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(DefaultOperationTimeout),
+			Update: schema.DefaultTimeout(DefaultOperationTimeout),
+			Delete: schema.DefaultTimeout(DefaultOperationTimeout),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"auth_server_id": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "The name clients request the scope by",
+			},
+			"display_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "The name shown to users asked to consent to the scope",
+			},
+			"description": &schema.Schema{
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"consent": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "IMPLICIT",
+				ValidateFunc: validation.StringInSlice([]string{"IMPLICIT", "REQUIRED"}, false),
+				Description:  "Whether users are asked to consent to the scope",
+			},
+			"metadata_publish": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "NO_CLIENTS",
+				ValidateFunc: validation.StringInSlice([]string{"ALL_CLIENTS", "NO_CLIENTS"}, false),
+				Description:  "Whether the scope is listed in the server's metadata",
+			},
+			"default": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Grant the scope to clients that don't request any",
+			},
+		},
+	}
+}
+
+func authServerScope(d *schema.ResourceData) api.OktaAuthServerScope {
+	return api.OktaAuthServerScope{
+		ID:              d.Id(),
+		Name:            d.Get("name").(string),
+		DisplayName:     d.Get("display_name").(string),
+		Description:     d.Get("description").(string),
+		Consent:         d.Get("consent").(string),
+		MetadataPublish: d.Get("metadata_publish").(string),
+		Default:         d.Get("default").(bool),
+	}
+}
+
+func resourceAuthServerScopeCreate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	scope, err := client.CreateAuthServerScope(ctx, d.Get("auth_server_id").(string), authServerScope(d))
+	if err != nil {
+		return err
+	}
+
+	d.SetId(scope.ID)
+	return resourceAuthServerScopeRead(ctx, d, m)
+}
+
+func resourceAuthServerScopeRead(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	scope, err := client.GetAuthServerScope(ctx, d.Get("auth_server_id").(string), d.Id())
+	if err != nil {
+		return err
+	}
+
+	if scope == nil {
+		log.Printf("[WARN] Okta authorization server scope not found, removing from state: %s", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("name", scope.Name)
+	d.Set("display_name", scope.DisplayName)
+	d.Set("description", scope.Description)
+	d.Set("consent", scope.Consent)
+	d.Set("metadata_publish", scope.MetadataPublish)
+	d.Set("default", scope.Default)
+
+	return nil
+}
+
+func resourceAuthServerScopeUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	if _, err := client.UpdateAuthServerScope(ctx, d.Get("auth_server_id").(string), authServerScope(d)); err != nil {
+		return err
+	}
+
+	return resourceAuthServerScopeRead(ctx, d, m)
+}
+
+func resourceAuthServerScopeDelete(ctx context.Context, d *schema.ResourceData, m interface{}) error {
+	config := m.(*Config)
+	client := config.Okta
+
+	return client.DeleteAuthServerScope(ctx, d.Get("auth_server_id").(string), d.Id())
+}
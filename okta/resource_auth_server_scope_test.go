@@ -0,0 +1,88 @@
+package okta
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/Brightspace/terraform-provider-okta/okta/api/fake"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// testAuthServer adds an authorization server to the fake, returning its ID.
+func testAuthServer(t *testing.T, okta *fake.Okta) string {
+	server, err := okta.CreateAuthServer(context.Background(), api.OktaAuthServer{
+		Name:      "internal-apis",
+		Audiences: []string{"api://internal"},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return server.ID
+}
+
+func TestResourceAuthServerScope_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	serverID := testAuthServer(t, okta)
+	r := resourceAuthServerScope()
+
+	values := map[string]interface{}{
+		"auth_server_id": serverID,
+		"name":           "reports:read",
+		"consent":        "REQUIRED",
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	scope := okta.AuthServers[serverID].Scopes[d.Id()]
+	if scope == nil || scope.Name != "reports:read" || scope.Consent != "REQUIRED" || scope.MetadataPublish != "NO_CLIENTS" {
+		t.Fatalf("expected the scope to be created, got %+v", scope)
+	}
+
+	values["default"] = true
+	values["display_name"] = "Read reports"
+	state := d.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	scope = okta.AuthServers[serverID].Scopes[state.ID]
+	if !scope.Default || scope.DisplayName != "Read reports" {
+		t.Fatalf("expected the scope to be updated, got %+v", scope)
+	}
+
+	d = r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.AuthServers[serverID].Scopes[state.ID]; ok {
+		t.Fatalf("expected scope %s to be deleted", state.ID)
+	}
+}
+
+func TestResourceAuthServerScope_readRemovesMissingScope(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAuthServerScope()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"auth_server_id": testAuthServer(t, okta),
+	})
+	d.SetId("scpmissing")
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("expected the scope to be removed from state")
+	}
+}
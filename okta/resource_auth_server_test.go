@@ -0,0 +1,136 @@
+package okta
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourceAuthServer_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAuthServer()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":                      "internal-apis",
+		"audiences":                 []interface{}{"api://internal"},
+		"credentials_rotation_mode": "MANUAL",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	server, ok := okta.AuthServers[d.Id()]
+	if !ok {
+		t.Fatalf("expected authorization server %s to be created", d.Id())
+	}
+
+	if server.Credentials.Signing.RotationMode != "MANUAL" {
+		t.Fatalf("expected MANUAL key rotation, got %s", server.Credentials.Signing.RotationMode)
+	}
+
+	if d.Get("issuer").(string) != server.Issuer || d.Get("kid").(string) == "" {
+		t.Fatalf("expected the issuer and key to be read, got %q and %q", d.Get("issuer"), d.Get("kid"))
+	}
+
+	if d.Get("credentials_next_rotation").(string) != "2020-04-01T00:00:00Z" {
+		t.Fatalf("expected the next rotation to be read, got %q", d.Get("credentials_next_rotation"))
+	}
+
+	values := map[string]interface{}{
+		"name":      "internal-apis",
+		"audiences": []interface{}{"api://internal"},
+		"status":    "INACTIVE",
+	}
+
+	state := d.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if server.Status != "INACTIVE" || server.Credentials.Signing.RotationMode != "AUTO" {
+		t.Fatalf("expected an inactive server rotating its keys, got %s and %s", server.Status, server.Credentials.Signing.RotationMode)
+	}
+
+	values["status"] = "ACTIVE"
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if server.Status != "ACTIVE" {
+		t.Fatalf("expected the server to be activated, got %s", server.Status)
+	}
+
+	d = r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.AuthServers[d.Id()]; ok {
+		t.Fatalf("expected authorization server %s to be deleted", d.Id())
+	}
+}
+
+func TestResourceAuthServer_createInactive(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourceAuthServer()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":      "internal-apis",
+		"audiences": []interface{}{"api://internal"},
+		"status":    "INACTIVE",
+	})
+
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if okta.AuthServers[d.Id()].Status != "INACTIVE" || d.Get("status").(string) != "INACTIVE" {
+		t.Fatalf("expected the server to be deactivated once created")
+	}
+}
+
+func TestResourceAuthServer_readRemovesMissingServer(t *testing.T) {
+	config, _, _ := testFakeConfig()
+	r := resourceAuthServer()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	d.SetId("ausmissing")
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if d.Id() != "" {
+		t.Fatalf("expected the authorization server to be removed from state")
+	}
+}
+
+func TestResourceAuthServer_errors(t *testing.T) {
+	for _, method := range []string{"CreateAuthServer", "GetAuthServer", "SetAuthServerActive"} {
+		config, okta, _ := testFakeConfig()
+		okta.Errors[method] = fmt.Errorf("%s failed", method)
+		r := resourceAuthServer()
+
+		d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+			"name":      "internal-apis",
+			"audiences": []interface{}{"api://internal"},
+			"status":    "INACTIVE",
+		})
+
+		if err := r.Create(d, config); err == nil {
+			t.Fatalf("expected an error when %s fails", method)
+		}
+	}
+}
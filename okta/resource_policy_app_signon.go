@@ -0,0 +1,12 @@
+package okta
+
+import (
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePolicyAppSignOn decides, through its rules, what users have to
+// verify to sign in to the apps it is assigned to. Who it applies to is up to
+// its rules.
+func resourcePolicyAppSignOn() *schema.Resource {
+	return policyResource("ACCESS_POLICY", map[string]*schema.Schema{}, nil, nil)
+}
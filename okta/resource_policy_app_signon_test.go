@@ -0,0 +1,34 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyAppSignOn_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourcePolicyAppSignOn()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":        "Payroll",
+		"description": "Payroll sign on",
+		"status":      "INACTIVE",
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy := okta.Policies[d.Id()]
+	if policy == nil || policy.Type != "ACCESS_POLICY" || policy.Status != "INACTIVE" || policy.Conditions != nil {
+		t.Fatalf("expected an inactive app sign-on policy, got %+v", policy)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Policies[d.Id()]; ok {
+		t.Fatalf("expected policy %s to be deleted", d.Id())
+	}
+}
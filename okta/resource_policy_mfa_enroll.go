@@ -0,0 +1,65 @@
+package okta
+
+import (
+	"fmt"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+var factorEnrollments = []string{"REQUIRED", "OPTIONAL", "NOT_ALLOWED"}
+
+// resourcePolicyMfaEnroll decides which factors the users in its groups
+// enroll in, and through its rules when they are asked to.
+func resourcePolicyMfaEnroll() *schema.Resource {
+	return policyResource("MFA_ENROLL", map[string]*schema.Schema{
+		"groups_included": groupsIncludedSchema(),
+		"factors": &schema.Schema{
+			Type:         schema.TypeMap,
+			Required:     true,
+			Elem:         &schema.Schema{Type: schema.TypeString},
+			ValidateFunc: validateFactorEnrollments,
+			Description:  "Whether users have to enroll in each factor, keyed by its Okta name such as okta_otp or google_otp: REQUIRED, OPTIONAL or NOT_ALLOWED. Factors left out keep their setting in Okta",
+		},
+	}, policyMfaEnroll, setPolicyMfaEnroll)
+}
+
+func validateFactorEnrollments(v interface{}, k string) (ws []string, errors []error) {
+	for factor, enroll := range v.(map[string]interface{}) {
+		valid := false
+		for _, enrollment := range factorEnrollments {
+			valid = valid || enroll == enrollment
+		}
+
+		if !valid {
+			errors = append(errors, fmt.Errorf("%s.%s must be one of %v, got: %v", k, factor, factorEnrollments, enroll))
+		}
+	}
+	return
+}
+
+func policyMfaEnroll(d *schema.ResourceData, policy *api.OktaPolicy) {
+	factors := map[string]api.OktaFactorSettings{}
+	for factor, enroll := range d.Get("factors").(map[string]interface{}) {
+		factors[factor] = api.OktaFactorSettings{Enroll: api.OktaFactorEnroll{Self: enroll.(string)}}
+	}
+
+	policy.Conditions = &api.OktaPolicyConditions{People: policyPeople(d)}
+	policy.Settings = &api.OktaPolicySettings{Factors: factors}
+}
+
+// setPolicyMfaEnroll only reads back the factors that are managed, those
+// left out of the configuration are left alone.
+func setPolicyMfaEnroll(d *schema.ResourceData, policy *api.OktaPolicy) {
+	setPolicyPeople(d, policy.Conditions)
+
+	factors := map[string]interface{}{}
+	if policy.Settings != nil {
+		for factor := range d.Get("factors").(map[string]interface{}) {
+			if settings, ok := policy.Settings.Factors[factor]; ok {
+				factors[factor] = settings.Enroll.Self
+			}
+		}
+	}
+	d.Set("factors", factors)
+}
@@ -0,0 +1,53 @@
+package okta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyMfaEnroll_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourcePolicyMfaEnroll()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"name":            "Staff",
+		"groups_included": []interface{}{"00g1staff"},
+		"factors": map[string]interface{}{
+			"okta_otp":   "REQUIRED",
+			"google_otp": "OPTIONAL",
+		},
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy := okta.Policies[d.Id()]
+	if policy == nil || policy.Type != "MFA_ENROLL" || policy.Settings.Factors["okta_otp"].Enroll.Self != "REQUIRED" {
+		t.Fatalf("expected Okta Verify to be required, got %+v", policy)
+	}
+
+	// Okta returns every factor, only those configured are read back.
+	policy.Settings.Factors["okta_sms"] = api.OktaFactorSettings{Enroll: api.OktaFactorEnroll{Self: "NOT_ALLOWED"}}
+	policy.Settings.Factors["google_otp"] = api.OktaFactorSettings{Enroll: api.OktaFactorEnroll{Self: "REQUIRED"}}
+
+	if err := r.Read(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	factors := d.Get("factors").(map[string]interface{})
+	if len(factors) != 2 || factors["google_otp"] != "REQUIRED" {
+		t.Fatalf("expected the configured factors to be read back, got %v", factors)
+	}
+}
+
+func TestResourcePolicyMfaEnroll_validatesFactors(t *testing.T) {
+	r := resourcePolicyMfaEnroll()
+
+	_, errs := r.Schema["factors"].ValidateFunc(map[string]interface{}{"okta_otp": "MANDATORY"}, "factors")
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "factors.okta_otp must be one of") {
+		t.Fatalf("expected an error for an unknown enrollment, got %v", errs)
+	}
+}
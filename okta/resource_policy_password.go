@@ -0,0 +1,207 @@
+package okta
+
+import (
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// resourcePolicyPassword sets the password requirements of the users in its
+// groups, and through its rules what they can do about their password.
+// Recovery settings aren't managed, updates keep those set in Okta.
+func resourcePolicyPassword() *schema.Resource {
+	return policyResource("PASSWORD", map[string]*schema.Schema{
+		"groups_included": groupsIncludedSchema(),
+		"auth_provider": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "OKTA",
+			ForceNew:     true,
+			ValidateFunc: validation.StringInSlice([]string{"OKTA", "ACTIVE_DIRECTORY"}, false),
+			Description:  "Where the passwords of the users the policy applies to are mastered",
+		},
+		"password_min_length": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      8,
+			ValidateFunc: validation.IntBetween(4, 30),
+		},
+		"password_min_lowercase": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntBetween(0, 1),
+			Description:  "1 for passwords to need a lowercase letter",
+		},
+		"password_min_uppercase": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntBetween(0, 1),
+			Description:  "1 for passwords to need an uppercase letter",
+		},
+		"password_min_number": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      1,
+			ValidateFunc: validation.IntBetween(0, 1),
+			Description:  "1 for passwords to need a number",
+		},
+		"password_min_symbol": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validation.IntBetween(0, 1),
+			Description:  "1 for passwords to need a symbol",
+		},
+		"password_exclude_username": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     true,
+			Description: "Reject passwords containing the user's username",
+		},
+		"password_exclude_first_name": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Reject passwords containing the user's first name",
+		},
+		"password_exclude_last_name": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Reject passwords containing the user's last name",
+		},
+		"password_dictionary_lookup": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Reject commonly used passwords",
+		},
+		"password_max_age_days": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validation.IntBetween(0, 999),
+			Description:  "How many days a password lasts before it has to be changed, 0 for it to never expire",
+		},
+		"password_expire_warn_days": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validation.IntBetween(0, 30),
+			Description:  "How many days before a password expires users are warned",
+		},
+		"password_min_age_minutes": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validation.IntBetween(0, 9999),
+			Description:  "How long users have to wait before changing their password again",
+		},
+		"password_history_count": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      4,
+			ValidateFunc: validation.IntBetween(0, 30),
+			Description:  "How many of the user's previous passwords can't be reused",
+		},
+		"password_max_lockout_attempts": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      10,
+			ValidateFunc: validation.IntBetween(0, 100),
+			Description:  "How many failed sign in attempts lock users out, 0 to never lock them out",
+		},
+		"password_auto_unlock_minutes": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      0,
+			ValidateFunc: validation.IntBetween(0, 9999),
+			Description:  "How long until locked out users are unlocked, 0 for them to stay locked out until an admin unlocks them",
+		},
+		"password_show_lockout_failures": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Tell users when they have been locked out",
+		},
+	}, policyPassword, setPolicyPassword)
+}
+
+func policyPassword(d *schema.ResourceData, policy *api.OktaPolicy) {
+	excluded := []string{}
+	if d.Get("password_exclude_first_name").(bool) {
+		excluded = append(excluded, "firstName")
+	}
+	if d.Get("password_exclude_last_name").(bool) {
+		excluded = append(excluded, "lastName")
+	}
+
+	policy.Conditions = &api.OktaPolicyConditions{
+		People:       policyPeople(d),
+		AuthProvider: &api.OktaAuthProviderCondition{Provider: d.Get("auth_provider").(string)},
+	}
+	policy.Settings = &api.OktaPolicySettings{
+		Password: &api.OktaPasswordSettings{
+			Complexity: api.OktaPasswordComplexity{
+				MinLength:         d.Get("password_min_length").(int),
+				MinLowerCase:      d.Get("password_min_lowercase").(int),
+				MinUpperCase:      d.Get("password_min_uppercase").(int),
+				MinNumber:         d.Get("password_min_number").(int),
+				MinSymbol:         d.Get("password_min_symbol").(int),
+				ExcludeUsername:   d.Get("password_exclude_username").(bool),
+				ExcludeAttributes: excluded,
+				Dictionary: api.OktaPasswordDictionary{
+					Common: api.OktaPasswordCommonDictionary{Exclude: d.Get("password_dictionary_lookup").(bool)},
+				},
+			},
+			Age: api.OktaPasswordAge{
+				MaxAgeDays:     d.Get("password_max_age_days").(int),
+				ExpireWarnDays: d.Get("password_expire_warn_days").(int),
+				MinAgeMinutes:  d.Get("password_min_age_minutes").(int),
+				HistoryCount:   d.Get("password_history_count").(int),
+			},
+			Lockout: api.OktaPasswordLockout{
+				MaxAttempts:         d.Get("password_max_lockout_attempts").(int),
+				AutoUnlockMinutes:   d.Get("password_auto_unlock_minutes").(int),
+				ShowLockoutFailures: d.Get("password_show_lockout_failures").(bool),
+			},
+		},
+	}
+}
+
+func setPolicyPassword(d *schema.ResourceData, policy *api.OktaPolicy) {
+	setPolicyPeople(d, policy.Conditions)
+
+	if policy.Conditions != nil && policy.Conditions.AuthProvider != nil {
+		d.Set("auth_provider", policy.Conditions.AuthProvider.Provider)
+	}
+
+	if policy.Settings == nil || policy.Settings.Password == nil {
+		return
+	}
+
+	password := policy.Settings.Password
+	excluded := map[string]bool{}
+	for _, attribute := range password.Complexity.ExcludeAttributes {
+		excluded[attribute] = true
+	}
+
+	d.Set("password_min_length", password.Complexity.MinLength)
+	d.Set("password_min_lowercase", password.Complexity.MinLowerCase)
+	d.Set("password_min_uppercase", password.Complexity.MinUpperCase)
+	d.Set("password_min_number", password.Complexity.MinNumber)
+	d.Set("password_min_symbol", password.Complexity.MinSymbol)
+	d.Set("password_exclude_username", password.Complexity.ExcludeUsername)
+	d.Set("password_exclude_first_name", excluded["firstName"])
+	d.Set("password_exclude_last_name", excluded["lastName"])
+	d.Set("password_dictionary_lookup", password.Complexity.Dictionary.Common.Exclude)
+	d.Set("password_max_age_days", password.Age.MaxAgeDays)
+	d.Set("password_expire_warn_days", password.Age.ExpireWarnDays)
+	d.Set("password_min_age_minutes", password.Age.MinAgeMinutes)
+	d.Set("password_history_count", password.Age.HistoryCount)
+	d.Set("password_max_lockout_attempts", password.Lockout.MaxAttempts)
+	d.Set("password_auto_unlock_minutes", password.Lockout.AutoUnlockMinutes)
+	d.Set("password_show_lockout_failures", password.Lockout.ShowLockoutFailures)
+}
@@ -0,0 +1,82 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyPassword_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourcePolicyPassword()
+
+	values := map[string]interface{}{
+		"name":                         "Staff",
+		"groups_included":              []interface{}{"00g1staff"},
+		"password_min_length":          12,
+		"password_min_symbol":          1,
+		"password_exclude_first_name":  true,
+		"password_dictionary_lookup":   true,
+		"password_max_age_days":        90,
+		"password_auto_unlock_minutes": 30,
+	}
+
+	d := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy := okta.Policies[d.Id()]
+	if policy == nil || policy.Type != "PASSWORD" || policy.Conditions.AuthProvider.Provider != "OKTA" {
+		t.Fatalf("expected an Okta password policy, got %+v", policy)
+	}
+
+	password := policy.Settings.Password
+	if password.Complexity.MinLength != 12 || password.Complexity.MinSymbol != 1 || !password.Complexity.Dictionary.Common.Exclude {
+		t.Fatalf("unexpected complexity %+v", password.Complexity)
+	}
+
+	if len(password.Complexity.ExcludeAttributes) != 1 || password.Complexity.ExcludeAttributes[0] != "firstName" {
+		t.Fatalf("expected the first name to be excluded, got %v", password.Complexity.ExcludeAttributes)
+	}
+
+	if password.Age.MaxAgeDays != 90 || password.Age.HistoryCount != 4 || password.Lockout.MaxAttempts != 10 || password.Lockout.AutoUnlockMinutes != 30 {
+		t.Fatalf("unexpected age %+v and lockout %+v", password.Age, password.Lockout)
+	}
+
+	state := d.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for an unchanged policy, got %#v", diff)
+	}
+
+	delete(values, "password_exclude_first_name")
+	values["password_exclude_last_name"] = true
+	values["password_max_lockout_attempts"] = 5
+
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if diff.RequiresNew() {
+		t.Fatalf("expected the policy to be updated in place, got %#v", diff)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	password = okta.Policies[state.ID].Settings.Password
+	if len(password.Complexity.ExcludeAttributes) != 1 || password.Complexity.ExcludeAttributes[0] != "lastName" || password.Lockout.MaxAttempts != 5 {
+		t.Fatalf("expected the last name to be excluded and 5 attempts, got %+v", password)
+	}
+
+	if state.Attributes["password_exclude_first_name"] != "false" || state.Attributes["password_exclude_last_name"] != "true" {
+		t.Fatalf("expected the excluded names to be read back, got %v", state.Attributes)
+	}
+}
@@ -0,0 +1,93 @@
+package okta
+
+import (
+	"regexp"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+var isoDurationPattern = regexp.MustCompile(`^P(\d+D)?(T(\d+H)?(\d+M)?(\d+S)?)?$`)
+
+// resourcePolicyRuleAppSignOn decides whether the people it matches can
+// sign in to the apps of its policy, and what they have to verify to.
+func resourcePolicyRuleAppSignOn() *schema.Resource {
+	return policyRuleResource("ACCESS_POLICY", map[string]*schema.Schema{
+		"groups_included": &schema.Schema{
+			Type:        schema.TypeSet,
+			Optional:    true,
+			Elem:        &schema.Schema{Type: schema.TypeString},
+			Set:         schema.HashString,
+			Description: "The IDs of the groups the rule applies to. It applies to everyone when neither groups nor users are given",
+		},
+		"groups_excluded": &schema.Schema{
+			Type:     schema.TypeSet,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Set:      schema.HashString,
+		},
+		"users_included": &schema.Schema{
+			Type:     schema.TypeSet,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeString},
+			Set:      schema.HashString,
+		},
+		"access": accessSchema("ALLOW", "Whether users can sign in to the apps"),
+		"factor_mode": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "2FA",
+			ValidateFunc: validation.StringInSlice([]string{"1FA", "2FA"}, false),
+			Description:  "Whether users have to verify one factor or two",
+		},
+		"reauthenticate_in": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "PT2H",
+			ValidateFunc: validation.StringMatch(isoDurationPattern, "must be an ISO 8601 duration, such as PT2H"),
+			Description:  "How long until users have to verify again",
+		},
+	}, policyRuleAppSignOn, setPolicyRuleAppSignOn, nil)
+}
+
+func policyRuleAppSignOn(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	people := rule.Conditions.People
+	people.Users.Include = stringSet(d, "users_included")
+	people.Groups = &api.OktaIncludeExclude{
+		Include: stringSet(d, "groups_included"),
+		Exclude: stringSet(d, "groups_excluded"),
+	}
+
+	rule.Actions.AppSignOn = &api.OktaAppSignOnAction{
+		Access: d.Get("access").(string),
+		VerificationMethod: api.OktaVerificationMethod{
+			Type:             "ASSURANCE",
+			FactorMode:       d.Get("factor_mode").(string),
+			ReauthenticateIn: d.Get("reauthenticate_in").(string),
+		},
+	}
+}
+
+func setPolicyRuleAppSignOn(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	users := []string{}
+	groups := api.OktaIncludeExclude{}
+	if people := rule.Conditions.People; people != nil {
+		if people.Users != nil {
+			users = people.Users.Include
+		}
+		if people.Groups != nil {
+			groups = *people.Groups
+		}
+	}
+
+	d.Set("users_included", users)
+	d.Set("groups_included", groups.Include)
+	d.Set("groups_excluded", groups.Exclude)
+
+	if signOn := rule.Actions.AppSignOn; signOn != nil {
+		d.Set("access", signOn.Access)
+		d.Set("factor_mode", signOn.VerificationMethod.FactorMode)
+		d.Set("reauthenticate_in", signOn.VerificationMethod.ReauthenticateIn)
+	}
+}
@@ -0,0 +1,59 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyRuleAppSignOn_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	policyID := testPolicy(t, okta, "ACCESS_POLICY")
+	r := resourcePolicyRuleAppSignOn()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"policy_id":         policyID,
+		"name":              "payroll",
+		"groups_included":   []interface{}{"00g1payroll"},
+		"groups_excluded":   []interface{}{"00g1contractors"},
+		"users_included":    []interface{}{"00u1auditor"},
+		"users_excluded":    []interface{}{"00u1leaver"},
+		"reauthenticate_in": "PT30M",
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule := okta.Policies[policyID].Rules[d.Id()]
+	if rule == nil || rule.Type != "ACCESS_POLICY" {
+		t.Fatalf("expected an app sign-on rule, got %+v", rule)
+	}
+
+	people := rule.Conditions.People
+	if people.Groups.Include[0] != "00g1payroll" || people.Groups.Exclude[0] != "00g1contractors" || people.Users.Include[0] != "00u1auditor" || people.Users.Exclude[0] != "00u1leaver" {
+		t.Fatalf("unexpected people %+v %+v", people.Users, people.Groups)
+	}
+
+	method := rule.Actions.AppSignOn.VerificationMethod
+	if method.Type != "ASSURANCE" || method.FactorMode != "2FA" || method.ReauthenticateIn != "PT30M" {
+		t.Fatalf("unexpected verification method %+v", method)
+	}
+
+	if d.Get("users_included").(*schema.Set).Len() != 1 || d.Get("users_excluded").(*schema.Set).Len() != 1 || d.Get("groups_excluded").(*schema.Set).Len() != 1 {
+		t.Fatalf("expected the people to be read back")
+	}
+}
+
+func TestResourcePolicyRuleAppSignOn_validatesReauthentication(t *testing.T) {
+	r := resourcePolicyRuleAppSignOn()
+
+	for _, duration := range []string{"PT2H", "PT30M", "P1D", "P1DT12H"} {
+		if _, errs := r.Schema["reauthenticate_in"].ValidateFunc(duration, "reauthenticate_in"); len(errs) != 0 {
+			t.Fatalf("expected %s to be valid, got %v", duration, errs)
+		}
+	}
+
+	if _, errs := r.Schema["reauthenticate_in"].ValidateFunc("2 hours", "reauthenticate_in"); len(errs) != 1 {
+		t.Fatalf("expected an error for a duration that isn't ISO 8601")
+	}
+}
@@ -0,0 +1,31 @@
+package okta
+
+import (
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// resourcePolicyRuleMfaEnroll decides when users are asked to enroll in the
+// factors of the policy.
+func resourcePolicyRuleMfaEnroll() *schema.Resource {
+	return policyRuleResource("MFA_ENROLL", map[string]*schema.Schema{
+		"enroll": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "CHALLENGE",
+			ValidateFunc: validation.StringInSlice([]string{"CHALLENGE", "LOGIN", "NEVER"}, false),
+			Description:  "When users are asked to enroll: the first time they are challenged for a factor (CHALLENGE), whenever they sign in until they have (LOGIN), or NEVER",
+		},
+	}, policyRuleMfaEnroll, setPolicyRuleMfaEnroll, nil)
+}
+
+func policyRuleMfaEnroll(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	rule.Actions.Enroll = &api.OktaEnrollAction{Self: d.Get("enroll").(string)}
+}
+
+func setPolicyRuleMfaEnroll(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	if rule.Actions.Enroll != nil {
+		d.Set("enroll", rule.Actions.Enroll.Self)
+	}
+}
@@ -0,0 +1,41 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyRuleMfaEnroll_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	policyID := testPolicy(t, okta, "MFA_ENROLL")
+	r := resourcePolicyRuleMfaEnroll()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"policy_id":          policyID,
+		"name":               "offsite",
+		"network_connection": "ZONE",
+		"network_excludes":   []interface{}{"nzo1office"},
+		"enroll":             "LOGIN",
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule := okta.Policies[policyID].Rules[d.Id()]
+	if rule == nil || rule.Type != "MFA_ENROLL" || rule.Actions.Enroll.Self != "LOGIN" {
+		t.Fatalf("expected users to enroll on sign in, got %+v", rule)
+	}
+
+	if network := rule.Conditions.Network; network.Connection != "ZONE" || len(network.Include) != 0 || network.Exclude[0] != "nzo1office" {
+		t.Fatalf("expected the office to be excluded, got %+v", network)
+	}
+
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Policies[policyID].Rules[d.Id()]; ok {
+		t.Fatalf("expected rule %s to be deleted", d.Id())
+	}
+}
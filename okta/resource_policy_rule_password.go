@@ -0,0 +1,34 @@
+package okta
+
+import (
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePolicyRulePassword decides what users can do about their password
+// themselves.
+func resourcePolicyRulePassword() *schema.Resource {
+	return policyRuleResource("PASSWORD", map[string]*schema.Schema{
+		"password_change": accessSchema("ALLOW", "Whether users can change their password"),
+		"password_reset":  accessSchema("ALLOW", "Whether users can reset their password when they have forgotten it"),
+		"password_unlock": accessSchema("DENY", "Whether users can unlock themselves when they are locked out"),
+	}, policyRulePassword, setPolicyRulePassword, nil)
+}
+
+func policyRulePassword(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	rule.Actions.PasswordChange = &api.OktaAccessAction{Access: d.Get("password_change").(string)}
+	rule.Actions.SelfServicePasswordReset = &api.OktaAccessAction{Access: d.Get("password_reset").(string)}
+	rule.Actions.SelfServiceUnlock = &api.OktaAccessAction{Access: d.Get("password_unlock").(string)}
+}
+
+func setPolicyRulePassword(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	if action := rule.Actions.PasswordChange; action != nil {
+		d.Set("password_change", action.Access)
+	}
+	if action := rule.Actions.SelfServicePasswordReset; action != nil {
+		d.Set("password_reset", action.Access)
+	}
+	if action := rule.Actions.SelfServiceUnlock; action != nil {
+		d.Set("password_unlock", action.Access)
+	}
+}
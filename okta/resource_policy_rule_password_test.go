@@ -0,0 +1,40 @@
+package okta
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyRulePassword_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	policyID := testPolicy(t, okta, "PASSWORD")
+	r := resourcePolicyRulePassword()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"policy_id":       policyID,
+		"name":            "self service",
+		"password_unlock": "ALLOW",
+	})
+	if err := r.Create(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule := okta.Policies[policyID].Rules[d.Id()]
+	if rule == nil || rule.Type != "PASSWORD" || rule.Conditions.Network.Connection != "ANYWHERE" {
+		t.Fatalf("expected a password rule applying anywhere, got %+v", rule)
+	}
+
+	actions := rule.Actions
+	if actions.PasswordChange.Access != "ALLOW" || actions.SelfServicePasswordReset.Access != "ALLOW" || actions.SelfServiceUnlock.Access != "ALLOW" {
+		t.Fatalf("expected users to manage their password themselves, got %+v", actions)
+	}
+
+	if actions.SignOn != nil || actions.Enroll != nil {
+		t.Fatalf("expected only the password actions to be set, got %+v", actions)
+	}
+
+	if d.Get("password_unlock").(string) != "ALLOW" || d.Get("network_connection").(string) != "ANYWHERE" {
+		t.Fatalf("expected the rule to be read back")
+	}
+}
@@ -0,0 +1,137 @@
+package okta
+
+import (
+	"fmt"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// The longest Okta allows a session to last, or a device to be remembered
+// for, ninety days.
+const maxSessionMinutes = 90 * 24 * 60
+
+// resourcePolicyRuleSignOn decides whether users are let into Okta, the
+// factors they are asked for and how long their session lasts.
+func resourcePolicyRuleSignOn() *schema.Resource {
+	return policyRuleResource("SIGN_ON", map[string]*schema.Schema{
+		"access": accessSchema("ALLOW", "Whether users are let in"),
+		"authtype": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			Default:      "ANY",
+			ValidateFunc: validation.StringInSlice([]string{"ANY", "RADIUS"}, false),
+			Description:  "RADIUS to only apply the rule to users authenticating through RADIUS",
+		},
+		"mfa_required": &schema.Schema{
+			Type:     schema.TypeBool,
+			Optional: true,
+			Default:  false,
+		},
+		"mfa_prompt": &schema.Schema{
+			Type:         schema.TypeString,
+			Optional:     true,
+			ValidateFunc: validation.StringInSlice([]string{"ALWAYS", "DEVICE", "SESSION"}, false),
+			Description:  "When users are asked for a factor, when mfa_required is set: ALWAYS, once per DEVICE or once per SESSION",
+		},
+		"mfa_remember_device": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Remember the device by default, when mfa_prompt is DEVICE",
+		},
+		"mfa_lifetime": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			ValidateFunc: validation.IntBetween(1, maxSessionMinutes),
+			Description:  "How long the device or session is remembered for in minutes, when mfa_prompt is DEVICE or SESSION",
+		},
+		"session_idle": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      120,
+			ValidateFunc: validation.IntBetween(1, maxSessionMinutes),
+			Description:  "How long a session can be idle before it expires",
+		},
+		"session_lifetime": &schema.Schema{
+			Type:         schema.TypeInt,
+			Optional:     true,
+			Default:      120,
+			ValidateFunc: validation.IntBetween(0, maxSessionMinutes),
+			Description:  "How long a session lasts, 0 for it not to be limited",
+		},
+		"session_persistent": &schema.Schema{
+			Type:        schema.TypeBool,
+			Optional:    true,
+			Default:     false,
+			Description: "Keep the session across browser restarts",
+		},
+	}, policyRuleSignOn, setPolicyRuleSignOn, resourcePolicyRuleSignOnCustomizeDiff)
+}
+
+// resourcePolicyRuleSignOnCustomizeDiff checks the factor settings are only
+// given when a factor is required, and that those Okta needs are there.
+func resourcePolicyRuleSignOnCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("access") || !d.NewValueKnown("mfa_required") || !d.NewValueKnown("mfa_prompt") || !d.NewValueKnown("mfa_lifetime") {
+		return nil
+	}
+
+	required := d.Get("mfa_required").(bool)
+	prompt := d.Get("mfa_prompt").(string)
+	remembered := prompt == "DEVICE" || prompt == "SESSION"
+
+	if required && d.Get("access").(string) == "DENY" {
+		return fmt.Errorf("mfa_required can only be set when access is ALLOW")
+	}
+	if required && prompt == "" {
+		return fmt.Errorf("mfa_prompt must be set when mfa_required is set")
+	}
+	if !required && prompt != "" {
+		return fmt.Errorf("mfa_prompt can only be set when mfa_required is set")
+	}
+	if remembered && d.Get("mfa_lifetime").(int) == 0 {
+		return fmt.Errorf("mfa_lifetime must be set when mfa_prompt is %s", prompt)
+	}
+	if !remembered && d.Get("mfa_lifetime").(int) != 0 {
+		return fmt.Errorf("mfa_lifetime can only be set when mfa_prompt is DEVICE or SESSION")
+	}
+
+	return nil
+}
+
+func policyRuleSignOn(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	rule.Conditions.AuthContext = &api.OktaAuthContextCondition{AuthType: d.Get("authtype").(string)}
+	rule.Actions.SignOn = &api.OktaSignOnAction{
+		Access:                  d.Get("access").(string),
+		RequireFactor:           d.Get("mfa_required").(bool),
+		FactorPromptMode:        d.Get("mfa_prompt").(string),
+		RememberDeviceByDefault: d.Get("mfa_remember_device").(bool),
+		FactorLifetime:          d.Get("mfa_lifetime").(int),
+		Session: api.OktaSignOnSession{
+			UsePersistentCookie:       d.Get("session_persistent").(bool),
+			MaxSessionIdleMinutes:     d.Get("session_idle").(int),
+			MaxSessionLifetimeMinutes: d.Get("session_lifetime").(int),
+		},
+	}
+}
+
+func setPolicyRuleSignOn(d *schema.ResourceData, rule *api.OktaPolicyRule) {
+	if rule.Conditions.AuthContext != nil {
+		d.Set("authtype", rule.Conditions.AuthContext.AuthType)
+	}
+
+	signOn := rule.Actions.SignOn
+	if signOn == nil {
+		return
+	}
+
+	d.Set("access", signOn.Access)
+	d.Set("mfa_required", signOn.RequireFactor)
+	d.Set("mfa_prompt", signOn.FactorPromptMode)
+	d.Set("mfa_remember_device", signOn.RememberDeviceByDefault)
+	d.Set("mfa_lifetime", signOn.FactorLifetime)
+	d.Set("session_idle", signOn.Session.MaxSessionIdleMinutes)
+	d.Set("session_lifetime", signOn.Session.MaxSessionLifetimeMinutes)
+	d.Set("session_persistent", signOn.Session.UsePersistentCookie)
+}
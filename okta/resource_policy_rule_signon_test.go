@@ -0,0 +1,123 @@
+package okta
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+func TestResourcePolicyRuleSignOn_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	policyID := testPolicy(t, okta, "OKTA_SIGN_ON")
+	r := resourcePolicyRuleSignOn()
+
+	offsite := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{
+		"policy_id": policyID,
+		"name":      "offsite",
+	})
+	if err := r.Create(offsite, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	values := map[string]interface{}{
+		"policy_id":          policyID,
+		"name":               "office",
+		"priority":           1,
+		"users_excluded":     []interface{}{"00u1breakglass"},
+		"network_connection": "ZONE",
+		"network_includes":   []interface{}{"nzo1office"},
+		"mfa_required":       true,
+		"mfa_prompt":         "SESSION",
+		"mfa_lifetime":       15,
+		"session_lifetime":   0,
+	}
+
+	office := schema.TestResourceDataRaw(t, r.Schema, values)
+	if err := r.Create(office, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rules := okta.Policies[policyID].Rules
+	if office.Get("priority").(int) != 1 || rules[offsite.Id()].Priority != 2 {
+		t.Fatalf("expected the offsite rule to be moved down, got %d", rules[offsite.Id()].Priority)
+	}
+
+	rule := rules[office.Id()]
+	if rule.Type != "SIGN_ON" || rule.Conditions.Network.Include[0] != "nzo1office" || rule.Conditions.People.Users.Exclude[0] != "00u1breakglass" {
+		t.Fatalf("expected the rule to apply in the office to everyone but the break glass user, got %+v", rule.Conditions)
+	}
+
+	signOn := rule.Actions.SignOn
+	if !signOn.RequireFactor || signOn.FactorPromptMode != "SESSION" || signOn.FactorLifetime != 15 || signOn.Session.MaxSessionLifetimeMinutes != 0 || signOn.Session.MaxSessionIdleMinutes != 120 {
+		t.Fatalf("unexpected sign on action %+v", signOn)
+	}
+
+	state := office.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !diff.Empty() {
+		t.Fatalf("expected no diff for an unchanged rule, got %#v", diff)
+	}
+
+	values["status"] = "INACTIVE"
+	values["access"] = "DENY"
+	values["mfa_required"] = false
+	delete(values, "mfa_prompt")
+	delete(values, "mfa_lifetime")
+
+	diff, err = r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	rule = rules[state.ID]
+	if rule.Status != "INACTIVE" || rule.Actions.SignOn.Access != "DENY" || rule.Actions.SignOn.RequireFactor {
+		t.Fatalf("expected an inactive rule denying access, got %+v", rule)
+	}
+
+	d := r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := rules[state.ID]; ok {
+		t.Fatalf("expected rule %s to be deleted", state.ID)
+	}
+}
+
+func TestResourcePolicyRuleSignOn_customizeDiff(t *testing.T) {
+	config, _, _ := testFakeConfig()
+	r := resourcePolicyRuleSignOn()
+
+	cases := []struct {
+		values map[string]interface{}
+		err    string
+	}{
+		{map[string]interface{}{"network_includes": []interface{}{"nzo1office"}}, "can only be set when network_connection is ZONE"},
+		{map[string]interface{}{"network_connection": "ZONE"}, "One of network_includes or network_excludes"},
+		{map[string]interface{}{"network_connection": "ZONE", "network_includes": []interface{}{"nzo1office"}, "network_excludes": []interface{}{"nzo1cafe"}}, "Only one of network_includes or network_excludes"},
+		{map[string]interface{}{"mfa_required": true}, "mfa_prompt must be set"},
+		{map[string]interface{}{"mfa_prompt": "ALWAYS"}, "mfa_prompt can only be set"},
+		{map[string]interface{}{"mfa_required": true, "mfa_prompt": "DEVICE"}, "mfa_lifetime must be set when mfa_prompt is DEVICE"},
+		{map[string]interface{}{"mfa_required": true, "mfa_prompt": "ALWAYS", "mfa_lifetime": 15}, "mfa_lifetime can only be set"},
+		{map[string]interface{}{"mfa_required": true, "mfa_prompt": "ALWAYS", "access": "DENY"}, "mfa_required can only be set when access is ALLOW"},
+	}
+
+	for _, c := range cases {
+		c.values["policy_id"] = "00p1signon"
+		c.values["name"] = "office"
+
+		_, err := r.Diff(nil, testResourceConfig(t, c.values), config)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Fatalf("expected %q for %v, got %v", c.err, c.values, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package okta
+
+import (
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// resourcePolicySignOn decides, through its rules, how the users in its
+// groups sign in to Okta.
+func resourcePolicySignOn() *schema.Resource {
+	return policyResource("OKTA_SIGN_ON", map[string]*schema.Schema{
+		"groups_included": groupsIncludedSchema(),
+	}, policySignOn, setPolicySignOn)
+}
+
+func policySignOn(d *schema.ResourceData, policy *api.OktaPolicy) {
+	policy.Conditions = &api.OktaPolicyConditions{People: policyPeople(d)}
+}
+
+func setPolicySignOn(d *schema.ResourceData, policy *api.OktaPolicy) {
+	setPolicyPeople(d, policy.Conditions)
+}
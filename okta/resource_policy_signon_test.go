@@ -0,0 +1,104 @@
+package okta
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Brightspace/terraform-provider-okta/okta/api"
+	"github.com/Brightspace/terraform-provider-okta/okta/api/fake"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// testPolicy creates a policy of the type for rules to be added to.
+func testPolicy(t *testing.T, okta *fake.Okta, policyType string) string {
+	policy, err := okta.CreatePolicy(context.Background(), api.OktaPolicy{
+		Type: policyType,
+		Name: "Staff " + policyType,
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return policy.ID
+}
+
+func TestResourcePolicySignOn_lifecycle(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	passwordID := testPolicy(t, okta, "PASSWORD")
+	r := resourcePolicySignOn()
+
+	create := func(name string, priority int) *schema.ResourceData {
+		values := map[string]interface{}{
+			"name":            name,
+			"groups_included": []interface{}{"00g1" + name},
+		}
+		if priority > 0 {
+			values["priority"] = priority
+		}
+
+		d := schema.TestResourceDataRaw(t, r.Schema, values)
+		if err := r.Create(d, config); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		return d
+	}
+
+	staff := create("staff", 0)
+	contractors := create("contractors", 0)
+	if staff.Get("priority").(int) != 1 || contractors.Get("priority").(int) != 2 {
+		t.Fatalf("expected policies without a priority to be added last, got %d and %d", staff.Get("priority"), contractors.Get("priority"))
+	}
+
+	admins := create("admins", 1)
+	if admins.Get("priority").(int) != 1 || okta.Policies[staff.Id()].Priority != 2 || okta.Policies[contractors.Id()].Priority != 3 {
+		t.Fatalf("expected the other policies to be moved down, got %d and %d", okta.Policies[staff.Id()].Priority, okta.Policies[contractors.Id()].Priority)
+	}
+
+	if okta.Policies[passwordID].Priority != 1 {
+		t.Fatalf("expected policies of other types to keep their priority, got %d", okta.Policies[passwordID].Priority)
+	}
+
+	values := map[string]interface{}{
+		"name":            "admins",
+		"description":     "Admins sign on",
+		"priority":        1,
+		"status":          "INACTIVE",
+		"groups_included": []interface{}{"00g1admins", "00g1owners"},
+	}
+
+	state := admins.State()
+	diff, err := r.Diff(state, testResourceConfig(t, values), config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if state, err = r.Apply(state, diff, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	policy := okta.Policies[state.ID]
+	if policy.Status != "INACTIVE" || policy.Description != "Admins sign on" || len(policy.Conditions.People.Groups.Include) != 2 {
+		t.Fatalf("expected an inactive policy for both groups, got %+v", policy)
+	}
+
+	d := r.Data(state)
+	if err := r.Delete(d, config); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, ok := okta.Policies[state.ID]; ok {
+		t.Fatalf("expected policy %s to be deleted", state.ID)
+	}
+}
+
+func TestResourcePolicySignOn_readsOnlyItsType(t *testing.T) {
+	config, okta, _ := testFakeConfig()
+	r := resourcePolicySignOn()
+
+	d := schema.TestResourceDataRaw(t, r.Schema, map[string]interface{}{})
+	d.SetId(testPolicy(t, okta, "PASSWORD"))
+
+	if err := r.Read(d, config); err == nil || !strings.Contains(err.Error(), "is a PASSWORD policy, not OKTA_SIGN_ON") {
+		t.Fatalf("expected an error reading a password policy, got %v", err)
+	}
+}
@@ -0,0 +1,30 @@
+package okta
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+)
+
+// statusSchema is the status of a resource Okta activates and deactivates
+// through its lifecycle, rather than by updating it.
+func statusSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Default:      "ACTIVE",
+		ValidateFunc: validation.StringInSlice([]string{"ACTIVE", "INACTIVE"}, false),
+	}
+}
+
+// stringSet returns the strings of the set attribute, sorted so requests
+// are the same from one run to the next.
+func stringSet(d resourceGetter, key string) []string {
+	values := []string{}
+	for _, value := range d.Get(key).(*schema.Set).List() {
+		values = append(values, value.(string))
+	}
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,91 @@
+package okta
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// Secrets are never written to state. Instead we keep a salted hash of them
+// so that a change in the configured value can still be detected.
+func newSecretSalt() (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+func hashSecret(salt string, values ...string) string {
+	mac := hmac.New(sha256.New, []byte(salt))
+	for _, value := range values {
+		mac.Write([]byte(value))
+		mac.Write([]byte{0})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// suppressStoredSecret hides the difference between the blank value kept in
+// state and the configured secret once the resource exists. Changes to the
+// secret are picked up by comparing hashes in CustomizeDiff instead.
+func suppressStoredSecret(k, old, new string, d *schema.ResourceData) bool {
+	return d.Id() != ""
+}
+
+// suppressMatchingSecrets is suppressStoredSecret for secrets that can be
+// changed in place. The difference is only hidden while the configured
+// secrets match the hash, so a changed secret is in the diff for the update
+// to read.
+func suppressMatchingSecrets(saltKey string, hashKey string, keys ...string) schema.SchemaDiffSuppressFunc {
+	return func(k, old, new string, d *schema.ResourceData) bool {
+		if d.Id() == "" {
+			return false
+		}
+
+		return hashSecret(d.Get(saltKey).(string), secretValues(d, keys...)...) == d.Get(hashKey).(string)
+	}
+}
+
+func secretValues(d resourceGetter, keys ...string) []string {
+	values := make([]string, len(keys))
+	for i, key := range keys {
+		values[i] = d.Get(key).(string)
+	}
+	return values
+}
+
+type resourceGetter interface {
+	Get(key string) interface{}
+}
+
+// resolveAwsCredentials returns the AWS keys from the resource configuration,
+// or from the shared credentials file when a profile is given instead.
+func resolveAwsCredentials(d resourceGetter) (string, string, error) {
+	accessKey := d.Get("aws_access_key").(string)
+	secretKey := d.Get("aws_secret_key").(string)
+	profile := d.Get("aws_profile").(string)
+	filename := d.Get("aws_shared_credentials_file").(string)
+
+	if accessKey != "" || secretKey != "" {
+		if accessKey == "" || secretKey == "" {
+			return "", "", fmt.Errorf("Both aws_access_key and aws_secret_key must be provided")
+		}
+		return accessKey, secretKey, nil
+	}
+
+	if profile == "" {
+		return "", "", fmt.Errorf("Either aws_access_key and aws_secret_key, or aws_profile must be provided")
+	}
+
+	value, err := credentials.NewSharedCredentials(filename, profile).Get()
+	if err != nil {
+		return "", "", fmt.Errorf("Could not load AWS credentials for profile %q: %s", profile, err)
+	}
+
+	return value.AccessKeyID, value.SecretAccessKey, nil
+}
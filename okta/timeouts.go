@@ -0,0 +1,44 @@
+package okta
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+const DefaultOperationTimeout = 20 * time.Minute
+
+// DiffTimeout bounds the calls to Okta made while planning, which have no
+// configurable timeout of their own.
+const DiffTimeout = 5 * time.Minute
+
+type contextFunc func(context.Context, *schema.ResourceData, interface{}) error
+
+// withTimeout runs the operation with a context that expires after the
+// timeout configured on the resource for the given operation, so calls to
+// Okta and any retries stop once the deadline is reached.
+func withTimeout(operation string, fn contextFunc) func(*schema.ResourceData, interface{}) error {
+	return func(d *schema.ResourceData, m interface{}) error {
+		timeout := d.Timeout(operation)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		err := fn(ctx, d, m)
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("Timed out after %s waiting for %s to complete: %s", timeout, operation, err)
+		}
+
+		return err
+	}
+}
+
+// diffTimeoutError explains an error from a call made while planning when
+// it was cut short by DiffTimeout.
+func diffTimeoutError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("Timed out after %s checking the plan: %s", DiffTimeout, err)
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -15,13 +16,13 @@ func main() {
 	arn := os.Args[2]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	result, err := client.CreateAwsApplication(name, arn)
+	result, err := client.CreateAwsApplication(context.Background(), name, arn)
 	if err != nil {
 		fmt.Println("Error:\n", err)
 		return
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -14,19 +15,19 @@ func main() {
 	appId := os.Args[1]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	err := client.DeactivateApplication(appId)
+	err := client.DeactivateApplication(context.Background(), appId)
 	if err != nil {
 		fmt.Println("Error:\n", err)
 		return
 	}
 
-	err = client.DeleteApplication(appId)
+	err = client.DeleteApplication(context.Background(), appId)
 	if err != nil {
 		fmt.Println("Error:\n", err)
 		return
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -14,13 +15,13 @@ func main() {
 	appId := os.Args[1]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	result, err := client.GetApplication(appId)
+	result, err := client.GetApplication(context.Background(), appId)
 	if result == nil {
 		fmt.Println("id could not be found:\n", appId)
 		return
@@ -32,9 +33,9 @@ func main() {
 	}
 
 	fmt.Println("ID:\n", result.ID)
-	fmt.Println("KeyID:\n", result.Credentials.Signing.KeyID)
+	fmt.Println("KeyID:\n", result.SigningKeyID())
 
-	saml, err := client.GetSAMLMetadata(result.ID, result.Credentials.Signing.KeyID)
+	saml, err := client.GetSAMLMetadata(context.Background(), result.ID, result.SigningKeyID())
 	if saml == "" {
 		fmt.Println("metadata could not be found:\n", appId)
 		return
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -16,13 +17,13 @@ func main() {
 	name := os.Args[2]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	app, err := client.GetApplication(appId)
+	app, err := client.GetApplication(context.Background(), appId)
 	if app == nil {
 		fmt.Println("id could not be found:\n", appId)
 		return
@@ -33,7 +34,7 @@ func main() {
 		return
 	}
 
-	result, err := client.UpdateAwsApplication(app.ID, name, app.Settings.App.IdentityProviderArn)
+	result, err := client.UpdateAwsApplication(context.Background(), app.ID, name, app.Settings.App.IdentityProviderArn)
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return
@@ -43,5 +44,5 @@ func main() {
 	fmt.Println("Name:\n", result.Name)
 	fmt.Println("Label:\n", result.Label)
 	fmt.Println("SignOnMode:\n", result.SignOnMode)
-	fmt.Println("Signing.KeyID:\n", result.Credentials.Signing.KeyID)
+	fmt.Println("Signing.KeyID:\n", result.SigningKeyID())
 }
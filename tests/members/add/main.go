@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -17,20 +18,20 @@ func main() {
 	samlRoles := []string{"SamlRole"}
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	user, err := client.AddAppMember(appId, userId, role, samlRoles)
+	user, err := client.AddAppMember(context.Background(), appId, userId, role, samlRoles)
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return
 	}
 	fmt.Println("added:\n", user)
 
-	result, _ := client.ListAppMembers(appId)
+	result, _ := client.ListAppMembers(context.Background(), appId)
 	for _, element := range result {
 		fmt.Println("ID:\n", element.ID)
 		fmt.Println("Username:\n", element.Profile.Email)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -15,19 +16,19 @@ func main() {
 	email := os.Args[2]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	userId, err := client.GetUserIDByEmail(email, "")
+	userId, err := client.GetUserIDByEmail(context.Background(), email, "")
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return
 	}
 
-	result, err := client.GetAppMember(appId, userId)
+	result, err := client.GetAppMember(context.Background(), appId, userId)
 	if result == nil {
 		fmt.Println("id could not be found:\n", appId)
 		return
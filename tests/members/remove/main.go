@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -15,19 +16,19 @@ func main() {
 	userId := os.Args[2]
 
 	client := api.Okta{
-		APIKey:       os.Getenv("OKTA_API_KEY"),
-		HostURL:      os.Getenv("OKTA_URL"),
-		OrgID:        os.Getenv("OKTA_ORG_ID"),
-		RetryMaximum: 5,
+		APIKey:      os.Getenv("OKTA_API_KEY"),
+		HostURL:     os.Getenv("OKTA_URL"),
+		OrgID:       os.Getenv("OKTA_ORG_ID"),
+		RetryPolicy: api.RetryPolicy{MaxRetries: 5},
 	}
 
-	err := client.RemoveAppMember(appId, userId)
+	err := client.RemoveAppMember(context.Background(), appId, userId, false)
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return
 	}
 
-	result, _ := client.ListAppMembers(appId)
+	result, _ := client.ListAppMembers(context.Background(), appId)
 	for _, element := range result {
 		fmt.Println("ID:\n", element.ID)
 		fmt.Println("Username:\n", element.Profile.Email)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -20,7 +21,7 @@ func main() {
 		HostURL:  os.Getenv("OKTA_URL"),
 		OrgID:    os.Getenv("OKTA_ORG_ID"),
 	}
-	err := client.RevokeAWSProvisioning(appId)
+	err := client.RevokeAWSProvisioning(context.Background(), appId)
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/Brightspace/terraform-provider-okta/okta/api"
 	"log"
@@ -23,7 +24,7 @@ func main() {
 	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
 	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
 
-	err := client.SetAWSProvisioning(appId, accessKey, secretKey)
+	err := client.SetAWSProvisioning(context.Background(), appId, accessKey, secretKey)
 	if err != nil {
 		fmt.Println("err:\n", err)
 		return